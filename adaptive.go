@@ -0,0 +1,160 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+// AdaptiveConfig configures the adaptive concurrency limit of a pool, which follows an AIMD scheme: the limit grows additively while
+// the latency is stable and no error occurs, and shrinks multiplicatively when the latency rises or errors occur.
+type AdaptiveConfig struct {
+	// Min and Max bound the limit. Min defaults to 1. If Max is 0, the limit is bounded only by the number of workers of the pool.
+	Min int
+	Max int
+	// Initial is the limit when the pool is created. Default is Min.
+	Initial int
+	// Increase is the amount by which the limit grows each time a number of inputs equal to the limit completes successfully
+	// while the limit is reached. Default is 1.
+	Increase float64
+	// Backoff is the factor, between 0 and 1, by which the limit is multiplied when the latency rises or an error occurs. Default is 0.7.
+	Backoff float64
+	// LatencyTolerance is the ratio between the recent latency and the long term latency above which the latency is considered risen.
+	// Default is 2.
+	LatencyTolerance float64
+	// IsFailure decides whether the error of an input shrinks the limit. If it is nil, all the errors but the ones of a cancelled context do.
+	IsFailure func(error) bool
+}
+
+// WithAdaptiveConcurrency makes the pool discover how many inputs it can process at the same time, rather than processing as many inputs
+// as its workers. The number of inputs processed at the same time is kept within a limit which adapts to the latency and the errors of the
+// processing, as configured. The limit is reported by ConcurrencyLimit and in the Stats.
+func WithAdaptiveConcurrency(config AdaptiveConfig) Option {
+	if config.Min < 1 {
+		config.Min = 1
+	}
+	if config.Max > 0 && config.Max < config.Min {
+		panic("the maximum concurrency limit must not be less than the minimum")
+	}
+	if config.Initial < config.Min {
+		config.Initial = config.Min
+	}
+	if config.Max > 0 && config.Initial > config.Max {
+		config.Initial = config.Max
+	}
+	if config.Increase <= 0 {
+		config.Increase = 1
+	}
+	if config.Backoff <= 0 || config.Backoff >= 1 {
+		config.Backoff = 0.7
+	}
+	if config.LatencyTolerance <= 1 {
+		config.LatencyTolerance = 2
+	}
+	return func(o *options) {
+		o.adaptive = &config
+	}
+}
+
+// ConcurrencyLimit returns the current limit of the inputs processed at the same time, or the number of workers of the pool if the pool
+// has no adaptive concurrency limit
+func (pool *Pool[I, O]) ConcurrencyLimit() int {
+	if pool.concurrency == nil {
+		return pool.Size()
+	}
+	return pool.concurrency.Limit()
+}
+
+// recentSmoothing and longTermSmoothing are the weights of the latest latency in the moving averages of the recent and the long term latency
+const (
+	recentSmoothing   = 0.5
+	longTermSmoothing = 0.05
+)
+
+// concurrencyLimiter keeps the number of inputs processed at the same time within a limit which adapts to the latency and the errors
+type concurrencyLimiter struct {
+	config   AdaptiveConfig
+	mu       sync.Mutex
+	limit    float64
+	inflight int
+	// recent and longTerm are the moving averages of the latency of the inputs processed successfully, zero until the first one
+	recent   float64
+	longTerm float64
+	// generation is incremented each time the limit shrinks, so that the inputs started before, which have run with a higher limit,
+	// do not shrink it again
+	generation int
+	// changed is closed, and replaced, each time a slot is freed or the limit changes, to wake up the workers waiting for a slot
+	changed chan struct{}
+}
+
+func newConcurrencyLimiter(config AdaptiveConfig) *concurrencyLimiter {
+	return &concurrencyLimiter{config: config, limit: float64(config.Initial), changed: make(chan struct{})}
+}
+
+// Limit returns the current limit
+func (l *concurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// acquire waits for a slot to process an input until ctx signals. Returns the generation in which the slot has been taken.
+func (l *concurrencyLimiter) acquire(ctx context.Context) (int, error) {
+	for {
+		l.mu.Lock()
+		if l.inflight < int(l.limit) {
+			l.inflight++
+			generation := l.generation
+			l.mu.Unlock()
+			return generation, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// release frees the slot taken in generation to process an input and adapts the limit to the latency and the error of the processing
+func (l *concurrencyLimiter) release(generation int, latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// the limit is reached if the slot freed was the last one available
+	limited := l.inflight >= int(l.limit)
+	l.inflight--
+	if err == nil {
+		if l.longTerm == 0 {
+			l.recent, l.longTerm = float64(latency), float64(latency)
+		}
+		l.recent += recentSmoothing * (float64(latency) - l.recent)
+		l.longTerm += longTermSmoothing * (float64(latency) - l.longTerm)
+	}
+	congested := l.isFailure(err) || l.recent > l.longTerm*l.config.LatencyTolerance
+	switch {
+	case congested && generation == l.generation:
+		l.limit = math.Max(float64(l.config.Min), math.Floor(l.limit*l.config.Backoff))
+		l.generation++
+	case !congested && limited:
+		l.limit += l.config.Increase / l.limit
+		if l.config.Max > 0 {
+			l.limit = math.Min(l.limit, float64(l.config.Max))
+		}
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+func (l *concurrencyLimiter) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if l.config.IsFailure != nil {
+		return l.config.IsFailure(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
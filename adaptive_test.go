@@ -0,0 +1,124 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// runAdaptive sends numOfInputs inputs to the pool and waits for them to be processed
+func runAdaptive(pool *workerpool.Pool[int, int], numOfInputs int) {
+	pool.Start(context.Background())
+	go func() {
+		for i := 0; i < numOfInputs; i++ {
+			pool.Process(i)
+		}
+		pool.Stop()
+	}()
+	for range pool.OutCh {
+	}
+	for range pool.ErrCh {
+	}
+}
+
+// TestPoolAdaptiveConcurrencyGrows checks that the limit grows while the latency is stable, without exceeding the maximum,
+// and that the inputs processed at the same time do not exceed the limit
+func TestPoolAdaptiveConcurrencyGrows(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	do := func(in int) (int, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return in, nil
+	}
+	max := 6
+	pool := workerpool.New(20, do,
+		workerpool.WithQueueSize(20),
+		workerpool.WithErrorBuffer(1),
+		// the tolerance is high so that a latency spike on a loaded machine does not shrink the limit
+		workerpool.WithAdaptiveConcurrency(workerpool.AdaptiveConfig{Max: max, LatencyTolerance: 100}),
+	)
+	if limit := pool.Stats().ConcurrencyLimit; limit != 1 {
+		t.Errorf("Expected initial limit %v - got %v", 1, limit)
+	}
+	runAdaptive(pool, 300)
+
+	if limit := pool.ConcurrencyLimit(); limit != max {
+		t.Errorf("Expected the limit to grow to %v - got %v", max, limit)
+	}
+	if maxRunning > max {
+		t.Errorf("Expected at most %v inputs processed at the same time - got %v", max, maxRunning)
+	}
+}
+
+// TestPoolAdaptiveConcurrencyErrors checks that the limit shrinks to the minimum when the processing fails
+func TestPoolAdaptiveConcurrencyErrors(t *testing.T) {
+	do := func(in int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return 0, errors.New("overloaded")
+	}
+	pool := workerpool.New(10, do, workerpool.WithAdaptiveConcurrency(workerpool.AdaptiveConfig{Min: 2, Initial: 10}))
+	pool.Start(context.Background())
+	go func() {
+		for i := 0; i < 50; i++ {
+			pool.Process(i)
+		}
+		pool.Stop()
+	}()
+	for range pool.ErrCh {
+	}
+	if limit := pool.ConcurrencyLimit(); limit != 2 {
+		t.Errorf("Expected the limit to shrink to %v - got %v", 2, limit)
+	}
+}
+
+// TestPoolAdaptiveConcurrencyLatency checks that the limit shrinks when the latency rises
+func TestPoolAdaptiveConcurrencyLatency(t *testing.T) {
+	var slow int32
+	var mu sync.Mutex
+	do := func(in int) (int, error) {
+		mu.Lock()
+		d := 2 * time.Millisecond
+		if slow == 1 {
+			d = 40 * time.Millisecond
+		}
+		mu.Unlock()
+		time.Sleep(d)
+		return in, nil
+	}
+	max := 8
+	pool := workerpool.New(max, do, workerpool.WithAdaptiveConcurrency(workerpool.AdaptiveConfig{Initial: max, Max: max, LatencyTolerance: 4}))
+	pool.Start(context.Background())
+	for i := 0; i < 100; i++ {
+		pool.Process(i)
+		<-pool.OutCh
+	}
+	// the limit does not grow since the inputs are processed one at a time, but may have been cut by a spike of latency
+	before := pool.ConcurrencyLimit()
+	if before < 2 {
+		t.Fatalf("Expected the limit to stay high while the latency is stable - got %v", before)
+	}
+	mu.Lock()
+	slow = 1
+	mu.Unlock()
+	pool.Process(0)
+	<-pool.OutCh
+	if limit := pool.ConcurrencyLimit(); limit >= before {
+		t.Errorf("Expected the limit to shrink below %v when the latency rises - got %v", before, limit)
+	}
+	go pool.Stop()
+	for range pool.OutCh {
+	}
+}
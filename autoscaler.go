@@ -0,0 +1,164 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Scalable is implemented by the pools which can be resized by an Autoscaler
+type Scalable interface {
+	Stats() Stats
+	Resize(n int) error
+}
+
+// AutoscalerConfig configures an Autoscaler.
+// The pool grows when at least one of the scale up conditions holds and shrinks when all the scale down conditions hold.
+// Since the thresholds to scale up are higher than the ones to scale down, the pool is not resized back and forth when the load
+// is between the two (hysteresis).
+type AutoscalerConfig struct {
+	// Min and Max are the bounds of the number of workers
+	Min int
+	Max int
+	// Interval is the period between two samples of the Stats of the pool. Default is 1 second.
+	Interval time.Duration
+	// Step is the number of workers added or removed at each resize. Default is 1.
+	Step int
+	// QueueHigh is the number of queued inputs above which the pool grows. Default is 0, i.e. the pool grows as soon as an input is queued.
+	// The pool shrinks only if no input is queued.
+	QueueHigh int
+	// UtilizationHigh is the fraction of busy workers above which the pool grows, and UtilizationLow the fraction below which
+	// the pool shrinks. Defaults are 0.8 and 0.3.
+	UtilizationHigh float64
+	UtilizationLow  float64
+	// LatencyHigh is the average processing time, over the last interval, above which the pool grows.
+	// The pool shrinks only if the average processing time is below LatencyHigh. Default is 0, i.e. latency is not considered.
+	LatencyHigh time.Duration
+	// UpCooldown and DownCooldown are the minimum periods after a resize before the pool can grow or shrink again
+	UpCooldown   time.Duration
+	DownCooldown time.Duration
+}
+
+// Autoscaler resizes a pool between a minimum and a maximum number of workers, depending on the backlog of its queue,
+// on the utilisation of its workers and on the latency of the processing
+type Autoscaler struct {
+	pool   Scalable
+	config AutoscalerConfig
+	// last is the previous sample and lastResize the time of the last resize
+	last       Stats
+	lastResize time.Time
+	cancel     context.CancelFunc
+	done       chan struct{}
+	once       sync.Once
+}
+
+// NewAutoscaler creates an Autoscaler for pool
+func NewAutoscaler(pool Scalable, config AutoscalerConfig) *Autoscaler {
+	if config.Min < 1 || config.Max < config.Min {
+		panic("min must be greater than 0 and max must not be less than min")
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Second
+	}
+	if config.Step < 1 {
+		config.Step = 1
+	}
+	if config.UtilizationHigh <= 0 {
+		config.UtilizationHigh = 0.8
+	}
+	if config.UtilizationLow <= 0 {
+		config.UtilizationLow = 0.3
+	}
+	return &Autoscaler{pool: pool, config: config, done: make(chan struct{})}
+}
+
+// Start launches the goroutine which samples the pool and resizes it. The goroutine completes when ctx signals,
+// when Stop is called or when the pool is stopped, i.e. its Stats report a terminal status or Resize fails.
+func (a *Autoscaler) Start(ctx context.Context) {
+	ctx, a.cancel = context.WithCancel(ctx)
+	a.last = a.pool.Stats()
+	go func() {
+		defer close(a.done)
+		ticker := time.NewTicker(a.config.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				if err := a.scale(now); err != nil {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the Autoscaler and waits for its goroutine to complete. It does nothing if the Autoscaler has not been started.
+func (a *Autoscaler) Stop() {
+	if a.cancel == nil {
+		return
+	}
+	a.once.Do(func() {
+		a.cancel()
+		<-a.done
+	})
+}
+
+// Done returns a channel which is closed when the goroutine launched by Start completes
+func (a *Autoscaler) Done() <-chan struct{} {
+	return a.done
+}
+
+// scale samples the pool and resizes it if needed. Returns ErrPoolStopped if the pool is in a terminal status.
+func (a *Autoscaler) scale(now time.Time) error {
+	current := a.pool.Stats()
+	if current.Status.Terminal() {
+		return ErrPoolStopped
+	}
+	n := a.decide(a.last, current, now)
+	a.last = current
+	if n == current.Size {
+		return nil
+	}
+	a.lastResize = now
+	return a.pool.Resize(n)
+}
+
+// decide returns the number of workers the pool should have given the previous and the current sample
+func (a *Autoscaler) decide(previous, current Stats, now time.Time) int {
+	c := a.config
+	size := current.Size
+	utilization := float64(current.Busy) / float64(size)
+	var latency time.Duration
+	if processed := current.Processed - previous.Processed; processed > 0 {
+		latency = (current.ProcessingTime - previous.ProcessingTime) / time.Duration(processed)
+	}
+	latencyHigh := c.LatencyHigh > 0 && latency > c.LatencyHigh
+	sinceResize := now.Sub(a.lastResize)
+
+	scaleUp := current.Queued > c.QueueHigh || utilization >= c.UtilizationHigh || latencyHigh
+	if scaleUp && size < c.Max && sinceResize >= c.UpCooldown {
+		return minInt(size+c.Step, c.Max)
+	}
+	scaleDown := current.Queued == 0 && utilization <= c.UtilizationLow && !latencyHigh
+	if scaleDown && size > c.Min && sinceResize >= c.DownCooldown {
+		return maxInt(size-c.Step, c.Min)
+	}
+	// keep the size within the bounds even if it has been changed by someone else
+	return maxInt(minInt(size, c.Max), c.Min)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
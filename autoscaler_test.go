@@ -0,0 +1,104 @@
+package workerpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestAutoscaler fills the queue of a pool with 1 worker and checks that the Autoscaler grows the pool up to its maximum size.
+// Then, once all the values have been processed, it checks that the Autoscaler shrinks the pool down to its minimum size.
+func TestAutoscaler(t *testing.T) {
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		<-release
+		return in, nil
+	}
+	numOfInputSentToPool := 20
+	pool := workerpool.New(1, do, workerpool.WithQueueSize(numOfInputSentToPool), workerpool.WithOutputBuffer(numOfInputSentToPool))
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	for i := 0; i < numOfInputSentToPool; i++ {
+		pool.Process(i)
+	}
+
+	config := workerpool.AutoscalerConfig{Min: 1, Max: 4, Interval: time.Millisecond}
+	autoscaler := workerpool.NewAutoscaler(pool, config)
+	autoscaler.Start(context.Background())
+	defer autoscaler.Stop()
+
+	// waitForSize waits for the pool to reach size n
+	waitForSize := func(n int) {
+		deadline := time.Now().Add(time.Second)
+		for pool.Size() != n && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if pool.Size() != n {
+			t.Fatalf("Expected size %v - got %v", n, pool.Size())
+		}
+	}
+
+	waitForSize(config.Max)
+	close(release)
+	waitForSize(config.Min)
+
+	autoscaler.Stop()
+	pool.Stop()
+	stats := pool.Stats()
+	if stats.Processed != int64(numOfInputSentToPool) {
+		t.Errorf("Expected number of values processed %v - got %v", numOfInputSentToPool, stats.Processed)
+	}
+}
+
+// TestAutoscalerCooldown checks that the Autoscaler does not resize the pool again before the cooldown period has passed
+func TestAutoscalerCooldown(t *testing.T) {
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		<-release
+		return in, nil
+	}
+	pool := workerpool.New(1, do, workerpool.WithQueueSize(10), workerpool.WithOutputBuffer(10))
+	pool.Start(context.Background())
+	defer pool.Stop()
+	defer close(release)
+	for i := 0; i < 10; i++ {
+		pool.Process(i)
+	}
+
+	config := workerpool.AutoscalerConfig{Min: 1, Max: 10, Interval: time.Millisecond, UpCooldown: time.Hour}
+	autoscaler := workerpool.NewAutoscaler(pool, config)
+	autoscaler.Start(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	autoscaler.Stop()
+
+	// the first resize happens right away, the following ones are prevented by the cooldown
+	if pool.Size() != 2 {
+		t.Errorf("Expected size %v - got %v", 2, pool.Size())
+	}
+}
+
+// TestAutoscalerPoolStopped checks that the Autoscaler completes when the pool is stopped, even if the pool does not need to be resized
+func TestAutoscalerPoolStopped(t *testing.T) {
+	pool := workerpool.New(1, func(in int) (int, error) { return in, nil })
+	pool.Start(context.Background())
+
+	autoscaler := workerpool.NewAutoscaler(pool, workerpool.AutoscalerConfig{Min: 1, Max: 4, Interval: time.Millisecond})
+	autoscaler.Start(context.Background())
+	pool.Stop()
+
+	select {
+	case <-autoscaler.Done():
+	case <-time.After(time.Second):
+		t.Error("The Autoscaler has not completed after the pool has been stopped")
+	}
+	autoscaler.Stop()
+}
+
+// TestAutoscalerStopNotStarted checks that an Autoscaler which has not been started can be stopped
+func TestAutoscalerStopNotStarted(t *testing.T) {
+	pool := workerpool.New(1, func(in int) (int, error) { return in, nil })
+	workerpool.NewAutoscaler(pool, workerpool.AutoscalerConfig{Min: 1, Max: 4}).Stop()
+}
@@ -0,0 +1,240 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker of a pool
+type CircuitState string
+
+// CircuitClosed is the state of a circuit breaker which lets all the calls through
+const CircuitClosed = CircuitState("Closed")
+
+// CircuitOpen is the state of a circuit breaker which rejects the calls because too many of the last ones have failed
+const CircuitOpen = CircuitState("Open")
+
+// CircuitHalfOpen is the state of a circuit breaker which lets one call through, the probe, to check whether the calls succeed again
+const CircuitHalfOpen = CircuitState("HalfOpen")
+
+// CircuitBreakerConfig configures the circuit breaker of a pool
+type CircuitBreakerConfig struct {
+	// WindowSize is the number of the last calls considered to compute the failure rate. Default is 20.
+	WindowSize int
+	// MinCalls is the minimum number of calls in the window before the circuit can open. Default is WindowSize.
+	MinCalls int
+	// FailureRate is the fraction of failed calls in the window, between 0 and 1, at which the circuit opens. Default is 0.5.
+	FailureRate float64
+	// ProbeInterval is the time the circuit stays open before letting a probe call through. Default is 1 second.
+	ProbeInterval time.Duration
+	// IsFailure decides whether the error returned by a call counts as a failure. If it is nil, all the errors but the ones
+	// of a cancelled context count as failures.
+	IsFailure func(error) bool
+	// PauseOnOpen makes the pool pause, instead of failing the processing of the inputs with ErrCircuitOpen, while the circuit is open.
+	// The pool is resumed when the probe interval expires and stays running if the probe succeeds.
+	// A pool paused with Pause is not resumed by the circuit breaker.
+	PauseOnOpen bool
+}
+
+// WithCircuitBreaker wraps the function of the pool with a circuit breaker.
+// While the circuit is open the processing of the inputs fails immediately with ErrCircuitOpen, or the pool is paused
+// if the PauseOnOpen field of the configuration is true.
+func WithCircuitBreaker(config CircuitBreakerConfig) Option {
+	if config.WindowSize <= 0 {
+		config.WindowSize = 20
+	}
+	if config.MinCalls <= 0 || config.MinCalls > config.WindowSize {
+		config.MinCalls = config.WindowSize
+	}
+	if config.FailureRate <= 0 {
+		config.FailureRate = 0.5
+	}
+	if config.ProbeInterval <= 0 {
+		config.ProbeInterval = time.Second
+	}
+	return func(o *options) {
+		o.breaker = &config
+	}
+}
+
+// breaker implements a circuit breaker with a sliding window of the outcomes of the last calls
+type breaker struct {
+	config CircuitBreakerConfig
+	mu     sync.Mutex
+	state  CircuitState
+	// outcomes is a ring buffer with the outcomes of the last calls, true for the failures
+	outcomes []bool
+	next     int
+	calls    int
+	failures int
+	// probing is true while the probe call is in flight
+	probing bool
+	// changed is closed, and replaced, at each change of state, to wake up the calls waiting for the circuit to close
+	changed chan struct{}
+	// onChange, if not nil, is called, without holding the lock, after each change of state
+	onChange func(CircuitState)
+}
+
+func newBreaker(config CircuitBreakerConfig) *breaker {
+	return &breaker{
+		config:   config,
+		state:    CircuitClosed,
+		outcomes: make([]bool, config.WindowSize),
+		changed:  make(chan struct{}),
+	}
+}
+
+// State returns the state of the circuit
+func (b *breaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow decides whether a call can go through. Returns true if the call is the probe of a half open circuit.
+// If the circuit does not let the call through, allow returns ErrCircuitOpen or, if the pool pauses on open, waits until ctx signals
+// or the call can go through.
+func (b *breaker) allow(ctx context.Context) (bool, error) {
+	for {
+		b.mu.Lock()
+		switch {
+		case b.state == CircuitClosed:
+			b.mu.Unlock()
+			return false, nil
+		case b.state == CircuitHalfOpen && !b.probing:
+			b.probing = true
+			b.mu.Unlock()
+			return true, nil
+		}
+		changed := b.changed
+		b.mu.Unlock()
+		if !b.config.PauseOnOpen {
+			return false, ErrCircuitOpen
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false, ctx.Err()
+		}
+	}
+}
+
+// record registers the outcome of a call which has gone through
+func (b *breaker) record(err error, probe bool) {
+	failed := b.isFailure(err)
+	b.mu.Lock()
+	var to CircuitState
+	switch {
+	case probe:
+		b.probing = false
+		switch {
+		case err == nil:
+			to = b.close()
+		case failed:
+			to = b.open()
+		default:
+			// a probe which does not fail, e.g. because it has been cancelled, does not prove that the calls succeed again:
+			// the circuit stays half open and the calls waiting are woken up, so that one of them can be the next probe
+			b.broadcast()
+		}
+	case b.state == CircuitClosed:
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+		b.outcomes[b.next] = failed
+		if failed {
+			b.failures++
+		}
+		b.next = (b.next + 1) % len(b.outcomes)
+		if b.calls < len(b.outcomes) {
+			b.calls++
+		}
+		if b.calls >= b.config.MinCalls && float64(b.failures)/float64(b.calls) >= b.config.FailureRate {
+			to = b.open()
+		}
+	}
+	onChange := b.onChange
+	b.mu.Unlock()
+	if to != "" && onChange != nil {
+		onChange(to)
+	}
+}
+
+// open moves the circuit to the open state and schedules the move to the half open state after the probe interval.
+// It must be called holding the lock and returns the new state.
+func (b *breaker) open() CircuitState {
+	b.setState(CircuitOpen)
+	time.AfterFunc(b.config.ProbeInterval, func() {
+		b.mu.Lock()
+		if b.state != CircuitOpen {
+			b.mu.Unlock()
+			return
+		}
+		b.setState(CircuitHalfOpen)
+		onChange := b.onChange
+		b.mu.Unlock()
+		if onChange != nil {
+			onChange(CircuitHalfOpen)
+		}
+	})
+	return CircuitOpen
+}
+
+// close moves the circuit to the closed state, clearing the outcomes of the past calls.
+// It must be called holding the lock and returns the new state.
+func (b *breaker) close() CircuitState {
+	b.setState(CircuitClosed)
+	b.outcomes = make([]bool, len(b.outcomes))
+	b.next, b.calls, b.failures = 0, 0, 0
+	return CircuitClosed
+}
+
+// setState changes the state and wakes up the calls waiting for a change. It must be called holding the lock.
+func (b *breaker) setState(state CircuitState) {
+	b.state = state
+	b.broadcast()
+}
+
+// broadcast wakes up the calls waiting for a change. It must be called holding the lock.
+func (b *breaker) broadcast() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+func (b *breaker) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if b.config.IsFailure != nil {
+		return b.config.IsFailure(err)
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// CircuitState returns the state of the circuit breaker of the pool, or CircuitClosed if the pool has no circuit breaker
+func (pool *Pool[I, O]) CircuitState() CircuitState {
+	if pool.breaker == nil {
+		return CircuitClosed
+	}
+	return pool.breaker.State()
+}
+
+// onCircuitChange pauses the pool when the circuit opens and resumes it when the circuit is half open, so that the probe can be processed.
+// It is used if the circuit breaker is configured to pause the pool. A pool paused by Pause is not resumed when the circuit is half open.
+func (pool *Pool[I, O]) onCircuitChange(state CircuitState) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	switch state {
+	case CircuitOpen:
+		if pool.transition(Paused) == nil {
+			pool.paused = make(chan struct{})
+			pool.pausedByCircuit = true
+		}
+	case CircuitHalfOpen:
+		if pool.pausedByCircuit && pool.transition(Running) == nil {
+			pool.openGate()
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+var errUnavailable = errors.New("service unavailable")
+
+// TestPoolCircuitBreaker checks that the circuit opens after too many failures, that the inputs then fail fast with ErrCircuitOpen
+// and that the circuit closes again when the probe succeeds
+func TestPoolCircuitBreaker(t *testing.T) {
+	var failing int32 = 1
+	var calls int32
+	do := func(in int) (int, error) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			return 0, errUnavailable
+		}
+		return in, nil
+	}
+	probeInterval := 50 * time.Millisecond
+	pool := workerpool.New(1, do, workerpool.WithCircuitBreaker(workerpool.CircuitBreakerConfig{
+		WindowSize:    4,
+		FailureRate:   0.5,
+		ProbeInterval: probeInterval,
+	}))
+	ctx := context.Background()
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	for i := 0; i < 4; i++ {
+		if _, err := pool.Submit(ctx, i).Await(ctx); !errors.Is(err, errUnavailable) {
+			t.Fatalf("Expected error %v - got %v", errUnavailable, err)
+		}
+	}
+	if state := pool.Stats().Circuit; state != workerpool.CircuitOpen {
+		t.Fatalf("Expected circuit %v - got %v", workerpool.CircuitOpen, state)
+	}
+	if _, err := pool.Submit(ctx, 4).Await(ctx); !errors.Is(err, workerpool.ErrCircuitOpen) {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrCircuitOpen, err)
+	}
+	if c := atomic.LoadInt32(&calls); c != 4 {
+		t.Errorf("Expected the function called %v times while the circuit is open - got %v", 4, c)
+	}
+
+	// the probe fails and the circuit opens again
+	time.Sleep(2 * probeInterval)
+	if state := pool.CircuitState(); state != workerpool.CircuitHalfOpen {
+		t.Fatalf("Expected circuit %v - got %v", workerpool.CircuitHalfOpen, state)
+	}
+	if _, err := pool.Submit(ctx, 5).Await(ctx); !errors.Is(err, errUnavailable) {
+		t.Errorf("Expected error %v - got %v", errUnavailable, err)
+	}
+	if state := pool.CircuitState(); state != workerpool.CircuitOpen {
+		t.Fatalf("Expected circuit %v - got %v", workerpool.CircuitOpen, state)
+	}
+
+	// the probe succeeds and the circuit closes
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(2 * probeInterval)
+	if out, err := pool.Submit(ctx, 6).Await(ctx); err != nil || out != 6 {
+		t.Errorf("Expected output %v - got %v with error %v", 6, out, err)
+	}
+	if state := pool.CircuitState(); state != workerpool.CircuitClosed {
+		t.Errorf("Expected circuit %v - got %v", workerpool.CircuitClosed, state)
+	}
+}
+
+// TestPoolCircuitBreakerRetry checks that, with a retry policy, an input fails fast when the circuit is open rather than being retried
+func TestPoolCircuitBreakerRetry(t *testing.T) {
+	do := func(in int) (int, error) {
+		return 0, errUnavailable
+	}
+	initialBackoff := 50 * time.Millisecond
+	pool := workerpool.New(1, do,
+		workerpool.WithRetry(workerpool.RetryPolicy{MaxAttempts: 4, InitialBackoff: initialBackoff}),
+		workerpool.WithCircuitBreaker(workerpool.CircuitBreakerConfig{WindowSize: 2, ProbeInterval: time.Minute}))
+	ctx := context.Background()
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	// the circuit opens after the second attempt, so the third one fails with ErrCircuitOpen and is not retried
+	_, err := pool.Submit(ctx, 1).Await(ctx)
+	var retryErr workerpool.RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Errors) != 3 {
+		t.Fatalf("Expected a RetryError with %v attempts - got %v", 3, err)
+	}
+	start := time.Now()
+	_, err = pool.Submit(ctx, 2).Await(ctx)
+	if err != workerpool.ErrCircuitOpen {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrCircuitOpen, err)
+	}
+	if elapsed := time.Since(start); elapsed >= initialBackoff {
+		t.Errorf("Expected the input to fail fast - it took %v", elapsed)
+	}
+}
+
+// TestPoolCircuitBreakerPause checks that a pool configured to pause on open does not fail the inputs while the circuit is open
+// but processes them once the circuit closes
+func TestPoolCircuitBreakerPause(t *testing.T) {
+	var failing int32 = 1
+	do := func(in int) (int, error) {
+		if atomic.LoadInt32(&failing) == 1 {
+			return 0, errUnavailable
+		}
+		return in, nil
+	}
+	numOfInputSentToPool := 10
+	pool := workerpool.New(2, do,
+		workerpool.WithQueueSize(numOfInputSentToPool),
+		workerpool.WithResults(),
+		workerpool.WithOutputBuffer(numOfInputSentToPool),
+		workerpool.WithCircuitBreaker(workerpool.CircuitBreakerConfig{
+			WindowSize:    2,
+			ProbeInterval: 20 * time.Millisecond,
+			PauseOnOpen:   true,
+		}))
+	ctx := context.Background()
+	pool.Start(ctx)
+	pool.Process(-1)
+	pool.Process(-2)
+
+	deadline := time.Now().Add(time.Second)
+	for pool.GetStatus() != workerpool.Paused {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected pool status %v - got %v", workerpool.Paused, pool.GetStatus())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	atomic.StoreInt32(&failing, 0)
+	for i := 0; i < numOfInputSentToPool-2; i++ {
+		pool.Process(i)
+	}
+	pool.Stop()
+
+	failed := 0
+	processed := 0
+	for res := range pool.ResultCh {
+		if errors.Is(res.Err, workerpool.ErrCircuitOpen) {
+			t.Errorf("Unexpected error %v for input %v", res.Err, res.Input)
+		}
+		if res.Err != nil {
+			failed++
+			continue
+		}
+		processed++
+	}
+	if failed < 2 {
+		t.Errorf("Expected at least %v failures - got %v", 2, failed)
+	}
+	if processed != numOfInputSentToPool-2 {
+		t.Errorf("Expected %v inputs processed - got %v", numOfInputSentToPool-2, processed)
+	}
+	if state := pool.CircuitState(); state != workerpool.CircuitClosed {
+		t.Errorf("Expected circuit %v - got %v", workerpool.CircuitClosed, state)
+	}
+}
+
+// TestPoolCircuitBreakerProbeCancelled checks that a cancelled probe does not close the circuit, which stays half open
+// so that the next call is the probe
+func TestPoolCircuitBreakerProbeCancelled(t *testing.T) {
+	var failing int32 = 1
+	started := make(chan struct{}, 1)
+	do := func(ctx context.Context, in int) (int, error) {
+		if atomic.LoadInt32(&failing) == 1 {
+			return 0, errUnavailable
+		}
+		if in == 0 {
+			started <- struct{}{}
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return in, nil
+	}
+	probeInterval := 20 * time.Millisecond
+	pool := workerpool.NewWithContext(1, do,
+		workerpool.WithCircuitBreaker(workerpool.CircuitBreakerConfig{WindowSize: 2, ProbeInterval: probeInterval}))
+	ctx := context.Background()
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	pool.Submit(ctx, 1).Await(ctx)
+	pool.Submit(ctx, 2).Await(ctx)
+	if state := pool.CircuitState(); state != workerpool.CircuitOpen {
+		t.Fatalf("Expected circuit %v - got %v", workerpool.CircuitOpen, state)
+	}
+	atomic.StoreInt32(&failing, 0)
+	time.Sleep(2 * probeInterval)
+
+	probe := pool.Submit(ctx, 0)
+	<-started
+	probe.Cancel()
+	<-probe.Done()
+	// the breaker records the outcome of the probe after the Future has been completed, before the worker is idle again
+	for pool.Stats().Busy > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if state := pool.CircuitState(); state != workerpool.CircuitHalfOpen {
+		t.Fatalf("Expected circuit %v after the probe has been cancelled - got %v", workerpool.CircuitHalfOpen, state)
+	}
+	if out, err := pool.Submit(ctx, 3).Await(ctx); err != nil || out != 3 {
+		t.Errorf("Expected output %v - got %v with error %v", 3, out, err)
+	}
+	if state := pool.CircuitState(); state != workerpool.CircuitClosed {
+		t.Errorf("Expected circuit %v - got %v", workerpool.CircuitClosed, state)
+	}
+}
+
+// TestPoolCircuitBreakerKeepsUserPause checks that the circuit breaker does not resume a pool paused with Pause
+func TestPoolCircuitBreakerKeepsUserPause(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		close(started)
+		<-release
+		return 0, errUnavailable
+	}
+	probeInterval := 20 * time.Millisecond
+	pool := workerpool.New(1, do, workerpool.WithCircuitBreaker(workerpool.CircuitBreakerConfig{
+		WindowSize:    1,
+		ProbeInterval: probeInterval,
+		PauseOnOpen:   true,
+	}))
+	ctx := context.Background()
+	pool.Start(ctx)
+	defer pool.Stop()
+
+	f := pool.Submit(ctx, 1)
+	<-started
+	if err := pool.Pause(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	close(release)
+	f.Await(ctx)
+	if state := pool.CircuitState(); state != workerpool.CircuitOpen {
+		t.Fatalf("Expected circuit %v - got %v", workerpool.CircuitOpen, state)
+	}
+
+	time.Sleep(3 * probeInterval)
+	if state := pool.CircuitState(); state != workerpool.CircuitHalfOpen {
+		t.Fatalf("Expected circuit %v - got %v", workerpool.CircuitHalfOpen, state)
+	}
+	if status := pool.GetStatus(); status != workerpool.Paused {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Paused, status)
+	}
+}
@@ -0,0 +1,190 @@
+package workerpool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetterRecord describes an input whose processing has failed, after all the attempts allowed by the retry policy of the pool
+type DeadLetterRecord[I any] struct {
+	Input I
+	Err   error
+	// Attempts is the number of times the function of the pool has been called for the input
+	Attempts int
+	// StartedAt is the time the processing of the input has started and FailedAt the time it has failed
+	StartedAt time.Time
+	FailedAt  time.Time
+}
+
+// DeadLetter receives the inputs whose processing has failed. A pool created with the WithDeadLetter option puts in its DeadLetter
+// a record for each failed input, besides emitting the error.
+// Put is called concurrently by the workers of the pool.
+type DeadLetter[I any] interface {
+	Put(record DeadLetterRecord[I])
+}
+
+// WithDeadLetter makes the pool put in deadLetter the inputs whose processing fails.
+// The inputs sent with Submit are not put in deadLetter since their errors are returned by their Future,
+// nor are the inputs interrupted because the pool is aborted.
+// deadLetter must be a DeadLetter of the type of the inputs of the pool, otherwise the constructor of the pool panics.
+func WithDeadLetter[I any](deadLetter DeadLetter[I]) Option {
+	return func(o *options) {
+		o.deadLetter = deadLetter
+	}
+}
+
+// MemoryDeadLetter is a DeadLetter which keeps the records in memory
+type MemoryDeadLetter[I any] struct {
+	mu      sync.Mutex
+	records []DeadLetterRecord[I]
+}
+
+// NewMemoryDeadLetter returns an empty MemoryDeadLetter
+func NewMemoryDeadLetter[I any]() *MemoryDeadLetter[I] {
+	return &MemoryDeadLetter[I]{}
+}
+
+// Put adds a record
+func (d *MemoryDeadLetter[I]) Put(record DeadLetterRecord[I]) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.records = append(d.records, record)
+}
+
+// Records returns a copy of the records put so far
+func (d *MemoryDeadLetter[I]) Records() []DeadLetterRecord[I] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]DeadLetterRecord[I]{}, d.records...)
+}
+
+// Inputs returns the inputs of the records put so far
+func (d *MemoryDeadLetter[I]) Inputs() []I {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	inputs := make([]I, len(d.records))
+	for i, r := range d.records {
+		inputs[i] = r.Input
+	}
+	return inputs
+}
+
+// jsonRecord is the JSON representation of a DeadLetterRecord, where the error is represented by its message
+type jsonRecord[I any] struct {
+	Input     I         `json:"input"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	StartedAt time.Time `json:"startedAt"`
+	FailedAt  time.Time `json:"failedAt"`
+}
+
+// FileDeadLetter is a DeadLetter which appends the records to a file, one JSON object per line.
+// The inputs must be values which can be encoded as JSON.
+type FileDeadLetter[I any] struct {
+	mu   sync.Mutex
+	file *os.File
+	// err is the first error occurred writing the file
+	err error
+}
+
+// NewFileDeadLetter opens the file at path, creating it if it does not exist, to append the records to it
+func NewFileDeadLetter[I any](path string) (*FileDeadLetter[I], error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileDeadLetter[I]{file: file}, nil
+}
+
+// Put appends a record to the file. If an error occurs, the records are not written any more and the error is returned by Close.
+func (d *FileDeadLetter[I]) Put(record DeadLetterRecord[I]) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		return
+	}
+	r := jsonRecord[I]{Input: record.Input, Attempts: record.Attempts, StartedAt: record.StartedAt, FailedAt: record.FailedAt}
+	if record.Err != nil {
+		r.Error = record.Err.Error()
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		d.err = err
+		return
+	}
+	_, d.err = d.file.Write(append(line, '\n'))
+}
+
+// Close closes the file and returns the first error occurred writing the records, if any
+func (d *FileDeadLetter[I]) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	err := d.file.Close()
+	if d.err != nil {
+		return d.err
+	}
+	return err
+}
+
+// ReadDeadLetterFile reads the records written by a FileDeadLetter in the file at path.
+// The errors of the records read carry only the message of the original errors.
+func ReadDeadLetterFile[I any](path string) ([]DeadLetterRecord[I], error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records := []DeadLetterRecord[I]{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var r jsonRecord[I]
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return records, fmt.Errorf("line %v of %v: %w", line, path, err)
+		}
+		record := DeadLetterRecord[I]{Input: r.Input, Attempts: r.Attempts, StartedAt: r.StartedAt, FailedAt: r.FailedAt}
+		if r.Error != "" {
+			record.Err = errors.New(r.Error)
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// DeadLetterInputs returns the inputs of the records in the file at path written by a FileDeadLetter, e.g. to pass them to a MapReduce run
+func DeadLetterInputs[I any](path string) ([]I, error) {
+	records, err := ReadDeadLetterFile[I](path)
+	if err != nil {
+		return nil, err
+	}
+	inputs := make([]I, len(records))
+	for i, r := range records {
+		inputs[i] = r.Input
+	}
+	return inputs, nil
+}
+
+// ProcessDeadLetterFile sends to the pool the inputs of the records in the file at path written by a FileDeadLetter.
+// The pool must have been started. Returns the number of inputs sent, which is less than the number of records if ctx signals
+// or the pool is stopped before all the inputs are sent.
+func (pool *Pool[I, O]) ProcessDeadLetterFile(ctx context.Context, path string) (int, error) {
+	inputs, err := DeadLetterInputs[I](path)
+	if err != nil {
+		return 0, err
+	}
+	for i, input := range inputs {
+		if err := pool.ProcessContext(ctx, input); err != nil {
+			return i, err
+		}
+	}
+	return len(inputs), nil
+}
@@ -0,0 +1,127 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+var errOdd = errors.New("odd input")
+
+func failOdd(in int) (int, error) {
+	if in%2 == 1 {
+		return 0, errOdd
+	}
+	return in, nil
+}
+
+// TestPoolDeadLetter checks that the inputs whose processing fails are put in the dead letter after all the attempts
+func TestPoolDeadLetter(t *testing.T) {
+	deadLetter := workerpool.NewMemoryDeadLetter[int]()
+	numOfInputSentToPool := 10
+	pool := workerpool.New(3, failOdd,
+		workerpool.WithDeadLetter[int](deadLetter),
+		workerpool.WithRetry(workerpool.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+		workerpool.WithErrorBuffer(numOfInputSentToPool),
+		workerpool.WithOutputBuffer(numOfInputSentToPool),
+	)
+	pool.Start(context.Background())
+	for i := 0; i < numOfInputSentToPool; i++ {
+		pool.Process(i)
+	}
+	pool.Stop()
+
+	errs := 0
+	for range pool.ErrCh {
+		errs++
+	}
+	records := deadLetter.Records()
+	if len(records) != numOfInputSentToPool/2 || errs != numOfInputSentToPool/2 {
+		t.Fatalf("Expected %v records and errors - got %v records and %v errors", numOfInputSentToPool/2, len(records), errs)
+	}
+	for _, r := range records {
+		if r.Input%2 != 1 {
+			t.Errorf("Unexpected input %v in the dead letter", r.Input)
+		}
+		if !errors.Is(r.Err, errOdd) {
+			t.Errorf("Expected error %v - got %v", errOdd, r.Err)
+		}
+		if r.Attempts != 2 {
+			t.Errorf("Expected attempts %v - got %v", 2, r.Attempts)
+		}
+		if r.StartedAt.IsZero() || r.FailedAt.Before(r.StartedAt) {
+			t.Errorf("Unexpected timestamps %v %v", r.StartedAt, r.FailedAt)
+		}
+	}
+}
+
+// TestPoolFileDeadLetter writes the failed inputs to a file and sends them again to a new pool, whose function does not fail
+func TestPoolFileDeadLetter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	deadLetter, err := workerpool.NewFileDeadLetter[int](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numOfInputSentToPool := 10
+	pool := workerpool.New(3, failOdd,
+		workerpool.WithDeadLetter[int](deadLetter),
+		workerpool.WithErrorBuffer(numOfInputSentToPool),
+		workerpool.WithOutputBuffer(numOfInputSentToPool),
+	)
+	pool.Start(context.Background())
+	for i := 0; i < numOfInputSentToPool; i++ {
+		pool.Process(i)
+	}
+	pool.Stop()
+	if err := deadLetter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := workerpool.ReadDeadLetterFile[int](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != numOfInputSentToPool/2 {
+		t.Fatalf("Expected %v records - got %v", numOfInputSentToPool/2, len(records))
+	}
+	if records[0].Err == nil || records[0].Err.Error() != errOdd.Error() || records[0].Attempts != 1 {
+		t.Errorf("Unexpected record %+v", records[0])
+	}
+
+	double := func(in int) (int, error) {
+		return in * 2, nil
+	}
+	refeedPool := workerpool.New(2, double, workerpool.WithOutputBuffer(numOfInputSentToPool))
+	refeedPool.Start(context.Background())
+	sent, err := refeedPool.ProcessDeadLetterFile(context.Background(), path)
+	if err != nil || sent != numOfInputSentToPool/2 {
+		t.Fatalf("Expected %v inputs sent - got %v with error %v", numOfInputSentToPool/2, sent, err)
+	}
+	refeedPool.Stop()
+	outputs := []int{}
+	for out := range refeedPool.OutCh {
+		outputs = append(outputs, out)
+	}
+	sort.Ints(outputs)
+	expected := []int{2, 6, 10, 14, 18}
+	for i := range expected {
+		if outputs[i] != expected[i] {
+			t.Fatalf("Expected outputs %v - got %v", expected, outputs)
+		}
+	}
+}
+
+// TestPoolDeadLetterWrongType checks that the constructor panics if the dead letter is not of the type of the inputs of the pool
+func TestPoolDeadLetterWrongType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	workerpool.New(1, failOdd, workerpool.WithDeadLetter[string](workerpool.NewMemoryDeadLetter[string]()))
+}
@@ -0,0 +1,189 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithDedup makes the pool coalesce the inputs sent with Submit which have the same key, as returned by keyFn, while an input with that
+// key is waiting or being processed: the input is processed once and its result is sent to the Future of each submitter.
+// A submitter which cancels its Future, or whose context signals, gets its own error and leaves the others waiting: the processing
+// is cancelled only when all the submitters have left.
+// The inputs sent with the other methods are not deduplicated.
+func WithDedup[I any, K comparable](keyFn func(input I) K) Option {
+	keyOf := func(input I) any {
+		return keyFn(input)
+	}
+	return func(o *options) {
+		o.dedupKeyOf = keyOf
+	}
+}
+
+// WithDedupWindow makes a pool created with the WithDedup option return, for the window after an input has been processed successfully,
+// the same result to the inputs with the same key sent with Submit, without processing them.
+func WithDedupWindow(window time.Duration) Option {
+	return func(o *options) {
+		o.dedupWindow = window
+	}
+}
+
+// flight is the processing of an input shared by the submitters of the inputs with the same key
+type flight[O any] struct {
+	// execution is the Future of the input processed, futures the ones returned to the submitters
+	execution *Future[O]
+	futures   []*Future[O]
+	// sendCtx bounds the wait for the input to be accepted by the pool and is cancelled by stopSend when the execution completes,
+	// i.e. also when all the submitters have left
+	sendCtx  context.Context
+	stopSend context.CancelFunc
+	// waiting is the number of submitters which have not cancelled their Future and landed is true once the execution has completed
+	waiting int
+	landed  bool
+}
+
+// recentResult is the result of an input processed successfully within the dedup window
+type recentResult[O any] struct {
+	output O
+	at     time.Time
+}
+
+// recentKey records when the result of a key has been stored, to drop it when the window expires
+type recentKey struct {
+	key any
+	at  time.Time
+}
+
+// dedup coalesces the inputs with the same key sent with Submit
+type dedup[I, O any] struct {
+	keyOf   func(I) any
+	window  time.Duration
+	mu      sync.Mutex
+	flights map[any]*flight[O]
+	recent  map[any]recentResult[O]
+	// expiries holds the keys of the recent results in the order they have been stored
+	expiries []recentKey
+}
+
+func newDedup[I, O any](keyOf func(I) any, window time.Duration) *dedup[I, O] {
+	return &dedup[I, O]{keyOf: keyOf, window: window, flights: map[any]*flight[O]{}, recent: map[any]recentResult[O]{}}
+}
+
+// join returns a Future for input and, if input has to be processed, the flight which processes it, nil if input joins the processing
+// of an input with the same key or gets a recent result. The Future is completed with the error of ctx if ctx signals before the result.
+func (d *dedup[I, O]) join(ctx context.Context, input I) (*Future[O], *flight[O]) {
+	key := d.keyOf(input)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.expire(time.Now())
+	if r, ok := d.recent[key]; ok {
+		f := newFuture[O]()
+		f.complete(r.output, nil)
+		return f, nil
+	}
+	fl, ok := d.flights[key]
+	var leader *flight[O]
+	if !ok {
+		execution := newFuture[O]()
+		sendCtx, stopSend := context.WithCancel(context.Background())
+		fl = &flight[O]{execution: execution, sendCtx: sendCtx, stopSend: stopSend}
+		d.flights[key] = fl
+		execution.onComplete = func(output O, err error) {
+			d.land(key, fl, output, err)
+		}
+		leader = fl
+	}
+	f := newFuture[O]()
+	// a submitter which leaves cancels the execution only if all the submitters have left.
+	// A submitter is counted once, however many times it leaves, and not at all once the execution has completed.
+	left := false
+	leave := func() {
+		d.mu.Lock()
+		if left || fl.landed {
+			d.mu.Unlock()
+			return
+		}
+		left = true
+		fl.waiting--
+		cancel := fl.waiting == 0
+		d.mu.Unlock()
+		if cancel {
+			fl.execution.Cancel()
+		}
+	}
+	f.cancel = leave
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				var zero O
+				f.complete(zero, ctx.Err())
+				leave()
+			case <-f.Done():
+			}
+		}()
+	}
+	fl.futures = append(fl.futures, f)
+	fl.waiting++
+	return f, leader
+}
+
+// land sends the result of the execution of a flight to all its submitters and, if the processing has succeeded, keeps it for the window
+func (d *dedup[I, O]) land(key any, fl *flight[O], output O, err error) {
+	d.mu.Lock()
+	fl.landed = true
+	if d.flights[key] == fl {
+		delete(d.flights, key)
+	}
+	if err == nil && d.window > 0 {
+		now := time.Now()
+		d.recent[key] = recentResult[O]{output: output, at: now}
+		d.expiries = append(d.expiries, recentKey{key: key, at: now})
+	}
+	futures := fl.futures
+	d.mu.Unlock()
+	fl.stopSend()
+	for _, f := range futures {
+		f.complete(output, err)
+	}
+}
+
+// expire drops the recent results older than the window. It must be called holding the lock.
+func (d *dedup[I, O]) expire(now time.Time) {
+	i := 0
+	for ; i < len(d.expiries) && now.Sub(d.expiries[i].at) >= d.window; i++ {
+		e := d.expiries[i]
+		// the result may have been stored again after e
+		if r, ok := d.recent[e.key]; ok && r.at.Equal(e.at) {
+			delete(d.recent, e.key)
+		}
+	}
+	d.expiries = d.expiries[i:]
+}
+
+// submitDedup sends input to the pool, unless an input with the same key is waiting or being processed or has been processed
+// within the dedup window
+func (pool *Pool[I, O]) submitDedup(ctx context.Context, input I) *Future[O] {
+	f, fl := pool.dedup.join(ctx, input)
+	if fl == nil {
+		return f
+	}
+	t := pool.newTask(input)
+	// the contexts of the submission and of the execution are not the one of the first submitter, whose cancellation must not
+	// cancel the processing for the other submitters: the execution is cancelled when all the submitters have left
+	t.index, t.future, t.ctx = -1, fl.execution, context.Background()
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		if err := pool.send(fl.sendCtx, t, true, false); err != nil {
+			var zero O
+			fl.execution.complete(zero, err)
+		}
+	}()
+	// like Submit, submitDedup returns when the input has been accepted, or when the submitter has left, e.g. because ctx signals
+	select {
+	case <-sent:
+	case <-f.Done():
+	}
+	return f
+}
@@ -0,0 +1,261 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolDedupCoalesces submits the same inputs while they are being processed and checks that each input is processed once
+// and that all the submitters receive its result
+func TestPoolDedupCoalesces(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		calls.Add(1)
+		<-release
+		return in * 10, nil
+	}
+	pool := workerpool.New(2, do, workerpool.WithDedup(func(in int) int { return in }))
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	ctx := context.Background()
+	var futures []*workerpool.Future[int]
+	for i := 0; i < 5; i++ {
+		futures = append(futures, pool.Submit(ctx, 1), pool.Submit(ctx, 2))
+	}
+	close(release)
+
+	for i, f := range futures {
+		out, err := f.Await(ctx)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if want := (i%2 + 1) * 10; out != want {
+			t.Errorf("Expected output %v - got %v", want, out)
+		}
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("Expected 2 inputs processed - got %v", n)
+	}
+
+	// once completed, without a window, an input is processed again
+	if _, err := pool.Submit(ctx, 1).Await(ctx); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Expected 3 inputs processed - got %v", n)
+	}
+}
+
+// TestPoolDedupCancel checks that the cancellation of the Future of a submitter does not interrupt the processing for the others,
+// while the cancellation of the Futures of all the submitters does
+func TestPoolDedupCancel(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+	interrupted := make(chan struct{}, 2)
+	do := func(ctx context.Context, in int) (int, error) {
+		started <- struct{}{}
+		select {
+		case <-ctx.Done():
+			interrupted <- struct{}{}
+			return 0, ctx.Err()
+		case <-release:
+			return in, nil
+		}
+	}
+	pool := workerpool.NewWithContext(1, do, workerpool.WithDedup(func(in int) int { return in }))
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	ctx := context.Background()
+	f1, f2 := pool.Submit(ctx, 1), pool.Submit(ctx, 1)
+	<-started
+	f1.Cancel()
+	if _, err := f1.Await(ctx); err != context.Canceled {
+		t.Errorf("Expected error %v - got %v", context.Canceled, err)
+	}
+	select {
+	case <-interrupted:
+		t.Fatal("The processing has been interrupted while a submitter is still waiting")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	if out, err := f2.Await(ctx); err != nil || out != 1 {
+		t.Errorf("Expected output 1 - got %v %v", out, err)
+	}
+
+	release = make(chan struct{})
+	f3, f4 := pool.Submit(ctx, 2), pool.Submit(ctx, 2)
+	<-started
+	f3.Cancel()
+	f4.Cancel()
+	select {
+	case <-interrupted:
+	case <-time.After(time.Second):
+		t.Error("The processing has not been interrupted when all the submitters have cancelled")
+	}
+	if _, err := f4.Await(ctx); err != context.Canceled {
+		t.Errorf("Expected error %v - got %v", context.Canceled, err)
+	}
+}
+
+// TestPoolDedupCancelTwice checks that a submitter which cancels its Future more than once does not cancel the processing
+// for the other submitters
+func TestPoolDedupCancelTwice(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	do := func(ctx context.Context, in int) (int, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-release:
+			return in, nil
+		}
+	}
+	pool := workerpool.NewWithContext(1, do, workerpool.WithDedup(func(in int) int { return in }))
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	ctx := context.Background()
+	f1, f2 := pool.Submit(ctx, 1), pool.Submit(ctx, 1)
+	<-started
+	f1.Cancel()
+	f1.Cancel()
+	close(release)
+	if out, err := f2.Await(ctx); err != nil || out != 1 {
+		t.Errorf("Expected output 1 - got %v %v", out, err)
+	}
+}
+
+// TestPoolDedupDeadlines checks that a submitter whose context expires while the pool is busy gets its own error without failing
+// the other submitters, and that the input is not processed if all the submitters have given up
+func TestPoolDedupDeadlines(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		if in == 0 {
+			<-release
+			return 0, nil
+		}
+		calls.Add(1)
+		return in * 10, nil
+	}
+	pool := workerpool.New(1, do, workerpool.WithDedup(func(in int) int { return in }), workerpool.WithOutputBuffer(1))
+	pool.Start(context.Background())
+	defer pool.Stop()
+	// the only worker is busy until release is closed
+	var releaseOnce sync.Once
+	releaseWorker := func() {
+		releaseOnce.Do(func() { close(release) })
+	}
+	defer releaseWorker()
+	pool.Process(0)
+
+	shortCtx, cancelShort := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancelShort()
+	longCtx, cancelLong := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelLong()
+	// the first submitter waits for the pool to accept the input, the second one joins its flight
+	shortSubmitted := make(chan *workerpool.Future[int], 1)
+	go func() {
+		shortSubmitted <- pool.Submit(shortCtx, 1)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	long := pool.Submit(longCtx, 1)
+	short := <-shortSubmitted
+	if _, err := short.Await(context.Background()); err != context.DeadlineExceeded {
+		t.Errorf("Expected error %v - got %v", context.DeadlineExceeded, err)
+	}
+	select {
+	case <-long.Done():
+		t.Fatal("The submitter with the longer deadline has been completed by the expiry of the other submitter")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// both submitters of 2 give up before the pool can accept the input
+	firstCtx, cancelFirst := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancelFirst()
+	secondCtx, cancelSecond := context.WithTimeout(context.Background(), 60*time.Millisecond)
+	defer cancelSecond()
+	firstSubmitted := make(chan *workerpool.Future[int], 1)
+	go func() {
+		firstSubmitted <- pool.Submit(firstCtx, 2)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	second := pool.Submit(secondCtx, 2)
+	for _, f := range []*workerpool.Future[int]{<-firstSubmitted, second} {
+		if _, err := f.Await(context.Background()); err != context.DeadlineExceeded {
+			t.Errorf("Expected error %v - got %v", context.DeadlineExceeded, err)
+		}
+	}
+
+	releaseWorker()
+	if out, err := long.Await(context.Background()); err != nil || out != 10 {
+		t.Errorf("Expected output 10 - got %v %v", out, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected 1 input processed - got %v", n)
+	}
+}
+
+// TestPoolDedupWindow checks that the successful results are returned without processing the inputs again within the window,
+// and that the errors are not
+func TestPoolDedupWindow(t *testing.T) {
+	var calls atomic.Int32
+	errOdd := errors.New("odd")
+	do := func(in int) (int, error) {
+		calls.Add(1)
+		if in%2 == 1 {
+			return 0, errOdd
+		}
+		return in * 10, nil
+	}
+	const window = 100 * time.Millisecond
+	pool := workerpool.New(1, do, workerpool.WithDedup(func(in int) int { return in }), workerpool.WithDedupWindow(window))
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if out, err := pool.Submit(ctx, 2).Await(ctx); err != nil || out != 20 {
+			t.Fatalf("Expected output 20 - got %v %v", out, err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("Expected 1 input processed within the window - got %v", n)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := pool.Submit(ctx, 3).Await(ctx); err != errOdd {
+			t.Fatalf("Expected error %v - got %v", errOdd, err)
+		}
+	}
+	if n := calls.Load(); n != 3 {
+		t.Errorf("Expected the failed input to be processed again - got %v inputs processed", n)
+	}
+
+	time.Sleep(window)
+	if out, err := pool.Submit(ctx, 2).Await(ctx); err != nil || out != 20 {
+		t.Fatalf("Expected output 20 - got %v %v", out, err)
+	}
+	if n := calls.Load(); n != 4 {
+		t.Errorf("Expected the input to be processed again after the window - got %v inputs processed", n)
+	}
+}
+
+// TestPoolDedupWindowWithoutDedup checks that the constructor panics if the dedup window is set without WithDedup
+func TestPoolDedupWindowWithoutDedup(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	workerpool.New(1, func(in int) (int, error) { return in, nil }, workerpool.WithDedupWindow(time.Second))
+}
@@ -0,0 +1,150 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+)
+
+// scheduler decides the order in which the tasks waiting in the queue of a pool are handed to the workers.
+// Its methods are called by the dispatcher holding its lock.
+type scheduler[I, O any] interface {
+	// push puts a task in the queue
+	push(t task[I, O])
+	// peek returns the next task to hand to the workers, false if there is none which can be handed now
+	peek() (task[I, O], bool)
+	// pop removes the task returned by peek, which has been handed to a worker
+	pop()
+	// len returns the number of tasks in the queue
+	len() int
+	// drain removes and returns all the tasks in the queue
+	drain() []task[I, O]
+}
+
+// completer is implemented by the schedulers which need to know when a task handed to the workers has been processed
+type completer[I, O any] interface {
+	completed(t task[I, O])
+}
+
+// holder is implemented by the schedulers which hold back tasks which can not be handed to the workers yet.
+// The tasks held back do not count towards the capacity of the dispatcher, so that they do not keep out the tasks which could be handed.
+type holder interface {
+	held() int
+}
+
+// dispatcher receives the tasks sent to a pool and hands them to the workers in the order decided by a scheduler
+type dispatcher[I, O any] struct {
+	// in receives the tasks sent to the pool and is closed when the pool is stopped
+	in chan task[I, O]
+	// capacity is the maximum number of tasks held which can be handed to the workers, no limit if it is negative
+	capacity  int
+	mu        sync.Mutex
+	scheduler scheduler[I, O]
+	// wake is signalled when a task which could not be handed to the workers may be handed now
+	wake chan struct{}
+	// done is closed when the dispatcher has handed all its tasks to the workers, or given them back if the pool is cancelled
+	done chan struct{}
+}
+
+func newDispatcher[I, O any](scheduler scheduler[I, O], capacity int) *dispatcher[I, O] {
+	return &dispatcher[I, O]{
+		in:        make(chan task[I, O]),
+		capacity:  capacity,
+		scheduler: scheduler,
+		wake:      make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+}
+
+// Len returns the number of tasks waiting in the queue
+func (d *dispatcher[I, O]) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.scheduler.len()
+}
+
+// notify wakes up the dispatcher, e.g. when a task which could not be handed to the workers may be handed now
+func (d *dispatcher[I, O]) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// completed tells the scheduler, if it needs to know, that a task handed to the workers has been processed, or given back
+// without being processed
+func (d *dispatcher[I, O]) completed(t task[I, O]) {
+	c, ok := d.scheduler.(completer[I, O])
+	if !ok {
+		return
+	}
+	d.mu.Lock()
+	c.completed(t)
+	d.mu.Unlock()
+	d.notify()
+}
+
+// next returns the next task to hand to the workers and whether the dispatcher accepts other tasks
+func (d *dispatcher[I, O]) next() (task[I, O], bool, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	t, ok := d.scheduler.peek()
+	n := d.scheduler.len()
+	if h, isHolder := d.scheduler.(holder); isHolder {
+		n -= h.held()
+	}
+	return t, ok, d.capacity < 0 || n < d.capacity
+}
+
+// run hands the tasks to the workers through out, which is closed when the dispatcher is closed and empty.
+// If ctx is cancelled, the tasks in the queue, and the ones still sent to it, are given back with putBack.
+func (d *dispatcher[I, O]) run(ctx context.Context, out chan<- task[I, O], putBack func(task[I, O])) {
+	defer close(d.done)
+	defer close(out)
+	in := d.in
+	for {
+		top, ok, room := d.next()
+		if in == nil && d.Len() == 0 {
+			return
+		}
+		// the dispatcher does not accept tasks when it is full and does not hand tasks when none can be handed
+		accept := in
+		if !room {
+			accept = nil
+		}
+		var hand chan<- task[I, O]
+		if ok {
+			hand = out
+		}
+		select {
+		case t, more := <-accept:
+			if !more {
+				in = nil
+				continue
+			}
+			d.mu.Lock()
+			d.scheduler.push(t)
+			d.mu.Unlock()
+		case hand <- top:
+			d.mu.Lock()
+			d.scheduler.pop()
+			d.mu.Unlock()
+		case <-d.wake:
+		case <-ctx.Done():
+			// the tasks still sent are queued too, so that the scheduler releases the resources they hold when it is drained
+			if in != nil {
+				for t := range in {
+					d.mu.Lock()
+					d.scheduler.push(t)
+					d.mu.Unlock()
+				}
+			}
+			d.mu.Lock()
+			left := d.scheduler.drain()
+			d.mu.Unlock()
+			for _, t := range left {
+				putBack(t)
+			}
+			return
+		}
+	}
+}
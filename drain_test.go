@@ -0,0 +1,101 @@
+package workerpool_test
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolDrain checks that Drain waits for all the values in the queue to be processed
+func TestPoolDrain(t *testing.T) {
+	do := func(in int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return in, nil
+	}
+	numOfInputSentToPool := 10
+	pool := workerpool.New(2, do, workerpool.WithQueueSize(numOfInputSentToPool), workerpool.WithOutputBuffer(numOfInputSentToPool))
+	pool.Start(context.Background())
+	for i := 0; i < numOfInputSentToPool; i++ {
+		pool.Process(i)
+	}
+
+	if err := pool.Drain(context.Background()); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	results := 0
+	for range pool.OutCh {
+		results++
+	}
+	if results != numOfInputSentToPool {
+		t.Errorf("Expected number of results %v - got %v", numOfInputSentToPool, results)
+	}
+	if pool.GetStatus() != workerpool.Stopped {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Stopped, pool.GetStatus())
+	}
+}
+
+// TestPoolDrainDeadline checks that Drain gives up when its context signals, cancelling the processing of the values
+func TestPoolDrainDeadline(t *testing.T) {
+	do := func(ctx context.Context, in int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	pool := workerpool.NewWithContext(1, do, workerpool.WithQueueSize(5))
+	pool.Start(context.Background())
+	for i := 0; i < 5; i++ {
+		pool.Process(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := pool.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected error %v - got %v", context.DeadlineExceeded, err)
+	}
+	if pool.GetStatus() != workerpool.Aborted {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Aborted, pool.GetStatus())
+	}
+}
+
+// TestPoolAbort checks that Abort cancels the value being processed and returns the values waiting in the queue
+func TestPoolAbort(t *testing.T) {
+	started := make(chan struct{}, 1)
+	interrupted := make(chan struct{}, 1)
+	do := func(ctx context.Context, in int) (int, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		interrupted <- struct{}{}
+		return 0, ctx.Err()
+	}
+	pool := workerpool.NewWithContext(1, do, workerpool.WithQueueSize(10))
+	pool.Start(context.Background())
+
+	pool.Process(0)
+	<-started
+	for i := 1; i < 5; i++ {
+		pool.Process(i)
+	}
+	f := pool.Submit(context.Background(), 5)
+
+	unprocessed := pool.Abort()
+
+	sort.Ints(unprocessed)
+	expected := []int{1, 2, 3, 4, 5}
+	if !reflect.DeepEqual(expected, unprocessed) {
+		t.Errorf("Expected unprocessed values %v - got %v", expected, unprocessed)
+	}
+	select {
+	case <-interrupted:
+	default:
+		t.Error("The value being processed has not been interrupted")
+	}
+	if _, err := f.Await(context.Background()); err != workerpool.ErrPoolStopped {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrPoolStopped, err)
+	}
+	if pool.GetStatus() != workerpool.Aborted {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Aborted, pool.GetStatus())
+	}
+}
@@ -0,0 +1,18 @@
+package workerpool
+
+import "errors"
+
+// ErrPoolStopped is returned when a value is sent to a pool which has been stopped
+var ErrPoolStopped = errors.New("the pool is stopped")
+
+// ErrPoolFull is returned when a value can not be sent to a pool because no worker is available to take it
+var ErrPoolFull = errors.New("the pool is full")
+
+// ErrPoolNotStarted is returned when a value is sent to a pool which has not been started yet
+var ErrPoolNotStarted = errors.New("the pool has not been started")
+
+// ErrPoolNotTerminated is returned when a pool which has not reached a terminal status is reset
+var ErrPoolNotTerminated = errors.New("the pool has not been stopped")
+
+// ErrCircuitOpen is returned as the error of the processing of an input when the circuit breaker of the pool is open
+var ErrCircuitOpen = errors.New("the circuit is open")
@@ -0,0 +1,80 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+)
+
+// Future is the handle to the result of an input sent to the pool with Submit
+type Future[O any] struct {
+	done   chan struct{}
+	once   sync.Once
+	output O
+	err    error
+	mu     sync.Mutex
+	// cancel interrupts the processing of the input, if it is running
+	cancel context.CancelFunc
+	// onComplete, if not nil, is called when the future is completed
+	onComplete func(output O, err error)
+}
+
+func newFuture[O any]() *Future[O] {
+	return &Future[O]{done: make(chan struct{})}
+}
+
+// Await waits for the processing of the input to complete and returns its output or its error.
+// If ctx signals before the processing completes, Await returns the error of ctx, while the processing goes on.
+func (f *Future[O]) Await(ctx context.Context) (O, error) {
+	select {
+	case <-f.done:
+		return f.output, f.err
+	case <-ctx.Done():
+		var zero O
+		return zero, ctx.Err()
+	}
+}
+
+// Done returns a channel which is closed when the processing of the input is completed
+func (f *Future[O]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel cancels the processing of the input. If the input is still waiting to be processed, it is not processed at all,
+// if it is being processed the context passed to the function of the pool is cancelled.
+// After Cancel, Await returns context.Canceled unless the processing had already completed.
+func (f *Future[O]) Cancel() {
+	var zero O
+	f.complete(zero, context.Canceled)
+	f.mu.Lock()
+	cancel := f.cancel
+	f.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+}
+
+// complete sets the output and the error of the future, if it has not been completed already
+func (f *Future[O]) complete(output O, err error) {
+	f.once.Do(func() {
+		f.output = output
+		f.err = err
+		close(f.done)
+		if f.onComplete != nil {
+			f.onComplete(output, err)
+		}
+	})
+}
+
+// start registers the function which interrupts the processing of the input.
+// Returns false if the future has already been completed, i.e. cancelled, and so the input must not be processed.
+func (f *Future[O]) start(cancel context.CancelFunc) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	select {
+	case <-f.done:
+		return false
+	default:
+	}
+	f.cancel = cancel
+	return true
+}
@@ -0,0 +1,132 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolSubmit submits some values to the pool and checks that each Future receives the result of its own input,
+// while other values are processed with Process and their results are received on the pool channels
+func TestPoolSubmit(t *testing.T) {
+	conversionError := errors.New("Error occurred while processing")
+	numberGeneratingError := 3
+	do := func(in int) (string, error) {
+		if in == numberGeneratingError {
+			return "", conversionError
+		}
+		return fmt.Sprintf("%v", in), nil
+	}
+	pool := workerpool.New(5, do)
+	pool.Start(context.Background())
+
+	// values processed with Process, whose results are received on OutCh
+	numOfInputProcessed := 100
+	producerDone := make(chan struct{})
+	go func() {
+		defer close(producerDone)
+		for i := 100; i < 100+numOfInputProcessed; i++ {
+			pool.Process(i)
+		}
+	}()
+	resultsReceived := 0
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range pool.OutCh {
+			resultsReceived++
+		}
+	}()
+
+	// values submitted, whose results are received by the futures
+	futures := []*workerpool.Future[string]{}
+	for i := 0; i < 10; i++ {
+		futures = append(futures, pool.Submit(context.Background(), i))
+	}
+	for i, f := range futures {
+		out, err := f.Await(context.Background())
+		if i == numberGeneratingError {
+			if err != conversionError {
+				t.Errorf("Expected error %v - got %v", conversionError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error %v", err)
+		}
+		if out != fmt.Sprintf("%v", i) {
+			t.Errorf("Expected output %v - got %v", i, out)
+		}
+	}
+
+	// stop the pool once all the values have been sent with Process
+	<-producerDone
+	pool.Stop()
+	wg.Wait()
+	if resultsReceived != numOfInputProcessed {
+		t.Errorf("Expected number of results %v - got %v", numOfInputProcessed, resultsReceived)
+	}
+}
+
+// TestFutureCancel cancels the Future of an input while it is being processed and checks that the context passed to the function is cancelled
+func TestFutureCancel(t *testing.T) {
+	started := make(chan struct{})
+	interrupted := make(chan struct{})
+	do := func(ctx context.Context, in int) (int, error) {
+		close(started)
+		select {
+		case <-ctx.Done():
+			close(interrupted)
+			return 0, ctx.Err()
+		case <-time.After(time.Second):
+			return in, nil
+		}
+	}
+	pool := workerpool.NewWithContext(1, do)
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	f := pool.Submit(context.Background(), 1)
+	<-started
+	f.Cancel()
+
+	select {
+	case <-f.Done():
+	case <-time.After(time.Second):
+		t.Fatal("The future has not been completed after Cancel")
+	}
+	if _, err := f.Await(context.Background()); err != context.Canceled {
+		t.Errorf("Expected error %v - got %v", context.Canceled, err)
+	}
+	select {
+	case <-interrupted:
+	case <-time.After(time.Second):
+		t.Error("The processing has not been interrupted by Cancel")
+	}
+}
+
+// TestFutureAwaitTimeout checks that Await returns when its context signals, even if the processing is not completed
+func TestFutureAwaitTimeout(t *testing.T) {
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		<-release
+		return in, nil
+	}
+	pool := workerpool.New(1, do)
+	pool.Start(context.Background())
+	defer pool.Stop()
+	defer close(release)
+
+	f := pool.Submit(context.Background(), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := f.Await(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected error %v - got %v", context.DeadlineExceeded, err)
+	}
+}
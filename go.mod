@@ -1,3 +1,3 @@
 module github.com/EnricoPicci/workerpool
 
-go 1.18
+go 1.20
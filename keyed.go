@@ -0,0 +1,350 @@
+package workerpool
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NewKeyed creates a Pool whose inputs with the same key, as returned by keyFn, are processed one at a time, in the order they have
+// been sent, while the inputs with different keys are processed in parallel.
+// The inputs are not bound to a worker: an input waits in the queue only while another input with the same key is being processed,
+// so a slow key does not hold back the inputs of the other keys: the inputs held back do not count towards the size of the queue
+// and can be bounded with WithKeyBacklog.
+// It is the same as creating a Pool with the option WithKeyLimit(keyFn, 1) and can not be used together with WithPriorities or
+// WithFairScheduling.
+func NewKeyed[I, O any, K comparable](size int, keyFn func(input I) K, do func(input I) (O, error), opts ...Option) *Pool[I, O] {
+	doWithContext := func(_ context.Context, input I) (O, error) {
+		return do(input)
+	}
+	return NewKeyedWithContext(size, keyFn, doWithContext, opts...)
+}
+
+// NewKeyedWithContext creates a keyed Pool, like NewKeyed, whose function receives a context, like the one of NewWithContext
+func NewKeyedWithContext[I, O any, K comparable](
+	size int,
+	keyFn func(input I) K,
+	do func(ctx context.Context, input I) (O, error),
+	opts ...Option,
+) *Pool[I, O] {
+	return NewWithContext(size, do, append(opts, WithKeyLimit(keyFn, 1))...)
+}
+
+// KeyLimitStats is a snapshot of the inputs held back by a pool created with the WithKeyLimit option
+type KeyLimitStats struct {
+	// Held is the number of inputs which have been held back because their key was saturated when they have been sent
+	Held int64
+	// HeldTime is the total time the inputs have been held back while their key was saturated
+	HeldTime time.Duration
+	// SaturatedKeys is the number of keys which have as many inputs being processed as the limit
+	SaturatedKeys int
+}
+
+// WithKeyLimit makes the pool process at most n inputs with the same key, as returned by keyFn, at the same time, e.g. to limit
+// the requests sent to each remote host. The inputs whose key is saturated are held back in the queue, without holding back the inputs
+// with other keys sent after them: they do not count towards the size of the queue and can be bounded with WithKeyBacklog.
+// The inputs with the same key are taken by the workers in the order they have been sent.
+// n must be greater than 0. It can not be used together with WithPriorities or WithFairScheduling.
+func WithKeyLimit[I any, K comparable](keyFn func(input I) K, n int) Option {
+	if n < 1 {
+		panic("the limit of inputs processed for each key must be greater than 0")
+	}
+	keyOf := func(input I) any {
+		return keyFn(input)
+	}
+	return func(o *options) {
+		o.keyOf = keyOf
+		o.keyLimit = n
+	}
+}
+
+// WithKeyBacklog bounds to n the inputs of each key waiting in the queue of a pool created with NewKeyed or WithKeyLimit.
+// The inputs held back because their key is saturated do not count towards the size of the queue, so by default they are not bounded.
+// With this option a sender whose key has already n inputs waiting waits for one of them to be taken by a worker, without holding back
+// the senders of the other keys. n must be greater than 0.
+func WithKeyBacklog(n int) Option {
+	if n < 1 {
+		panic("the backlog of each key must be greater than 0")
+	}
+	return func(o *options) {
+		o.keyBacklog = n
+	}
+}
+
+// KeyLimitStats returns a snapshot of the inputs held back by the pool, the zero value if the pool is not created with WithKeyLimit
+func (pool *Pool[I, O]) KeyLimitStats() KeyLimitStats {
+	if pool.keyCounters == nil {
+		return KeyLimitStats{}
+	}
+	pool.keyCounters.mu.Lock()
+	defer pool.keyCounters.mu.Unlock()
+	return pool.keyCounters.stats
+}
+
+// keyCounters counts the inputs held back by a pool with a key limit and survives the reset of the pool
+type keyCounters struct {
+	mu    sync.Mutex
+	stats KeyLimitStats
+}
+
+// keySlots bounds the inputs of each key waiting in the queue of a pool created with WithKeyBacklog, so that the senders of a key
+// whose inputs are held back wait for a slot without holding back the senders of the other keys. It survives the reset of the pool.
+type keySlots struct {
+	capacity int
+	mu       sync.Mutex
+	// waiting is the number of inputs of each key waiting in the queue
+	waiting map[any]int
+	// changed is closed, and replaced, when an input leaves the queue, to wake up the senders waiting for a slot
+	changed chan struct{}
+}
+
+func newKeySlots(capacity int) *keySlots {
+	return &keySlots{capacity: capacity, waiting: map[any]int{}, changed: make(chan struct{})}
+}
+
+// acquire takes a slot in the queue for key, waiting until ctx signals, the pool context signals or the pool is stopped, if wait is true
+func (ks *keySlots) acquire(ctx context.Context, poolCtx context.Context, stopping <-chan struct{}, key any, wait bool) error {
+	// poolDone is nil, i.e. it never signals, until the pool is started
+	var poolDone <-chan struct{}
+	if poolCtx != nil {
+		poolDone = poolCtx.Done()
+	}
+	for {
+		ks.mu.Lock()
+		if ks.waiting[key] < ks.capacity {
+			ks.waiting[key]++
+			ks.mu.Unlock()
+			return nil
+		}
+		changed := ks.changed
+		ks.mu.Unlock()
+		if !wait {
+			return ErrPoolFull
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-poolDone:
+			return poolCtx.Err()
+		case <-stopping:
+			return ErrPoolStopped
+		}
+	}
+}
+
+// release frees a slot in the queue for key, when an input of the key leaves the queue or is not sent
+func (ks *keySlots) release(key any) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.waiting[key]--
+	if ks.waiting[key] <= 0 {
+		delete(ks.waiting, key)
+	}
+	close(ks.changed)
+	ks.changed = make(chan struct{})
+}
+
+// keyQueue holds the tasks with the same key waiting in the queue
+type keyQueue[I, O any] struct {
+	key   any
+	tasks []queuedTask[I, O]
+	// running is the number of tasks with the key being processed
+	running int
+	// ready is the number of tasks of the queue which can be handed to the workers without exceeding the limit
+	ready int
+	// index is the position of the queue in the heap of the ready queues, -1 if the queue is not ready
+	index int
+	// heldSince is the last time the held time of the tasks has been accounted, zero if the key is not saturated
+	heldSince time.Time
+}
+
+// readyQueues is a heap of the queues whose first task can be handed to the workers, ordered by the sequence of their first task,
+// and implements heap.Interface
+type readyQueues[I, O any] []*keyQueue[I, O]
+
+func (h readyQueues[I, O]) Len() int { return len(h) }
+func (h readyQueues[I, O]) Less(i, j int) bool {
+	return h[i].tasks[0].seq < h[j].tasks[0].seq
+}
+func (h readyQueues[I, O]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *readyQueues[I, O]) Push(x any) {
+	q := x.(*keyQueue[I, O])
+	q.index = len(*h)
+	*h = append(*h, q)
+}
+func (h *readyQueues[I, O]) Pop() any {
+	old := *h
+	n := len(old)
+	q := old[n-1]
+	q.index = -1
+	*h = old[:n-1]
+	return q
+}
+
+// keyScheduler is the scheduler which hands the tasks in the order they have been sent, holding back the tasks whose key
+// has already as many tasks being processed as the limit
+type keyScheduler[I, O any] struct {
+	limit int
+	// queues holds the queues of the keys with tasks waiting or being processed
+	queues map[any]*keyQueue[I, O]
+	ready  readyQueues[I, O]
+	seq    uint64
+	count  int
+	// readyCount is the number of tasks which can be handed to the workers, the others are held back
+	readyCount int
+	counters   *keyCounters
+	slots      *keySlots
+}
+
+func newKeyScheduler[I, O any](limit int, counters *keyCounters, slots *keySlots) *keyScheduler[I, O] {
+	return &keyScheduler[I, O]{limit: limit, queues: map[any]*keyQueue[I, O]{}, counters: counters, slots: slots}
+}
+
+// account adds to the held time the time the tasks of the queue have been held since the last time it has been accounted.
+// It must be called before the tasks or the running count of the queue change.
+func (s *keyScheduler[I, O]) account(q *keyQueue[I, O]) {
+	if q.heldSince.IsZero() {
+		return
+	}
+	now := time.Now()
+	s.counters.mu.Lock()
+	s.counters.stats.HeldTime += now.Sub(q.heldSince) * time.Duration(len(q.tasks))
+	s.counters.mu.Unlock()
+	q.heldSince = now
+}
+
+func (s *keyScheduler[I, O]) len() int {
+	return s.count
+}
+
+// held returns the number of tasks held back because their key is saturated
+func (s *keyScheduler[I, O]) held() int {
+	return s.count - s.readyCount
+}
+
+// queue returns the queue of the key of t, creating it if needed
+func (s *keyScheduler[I, O]) queue(t task[I, O]) *keyQueue[I, O] {
+	q, ok := s.queues[t.key]
+	if !ok {
+		q = &keyQueue[I, O]{key: t.key, index: -1}
+		s.queues[t.key] = q
+	}
+	return q
+}
+
+// update puts the queue in the heap of the ready queues, or removes it, depending on whether its first task can be handed
+// to the workers, and forgets the queue if it has no task waiting or being processed
+func (s *keyScheduler[I, O]) update(q *keyQueue[I, O]) {
+	saturated := q.running >= s.limit
+	if saturated != !q.heldSince.IsZero() {
+		s.counters.mu.Lock()
+		if saturated {
+			q.heldSince = time.Now()
+			s.counters.stats.SaturatedKeys++
+		} else {
+			q.heldSince = time.Time{}
+			s.counters.stats.SaturatedKeys--
+		}
+		s.counters.mu.Unlock()
+	}
+	ready := len(q.tasks) > 0 && q.running < s.limit
+	readyTasks := 0
+	if ready {
+		readyTasks = minInt(len(q.tasks), s.limit-q.running)
+	}
+	s.readyCount += readyTasks - q.ready
+	q.ready = readyTasks
+	switch {
+	case ready && q.index < 0:
+		heap.Push(&s.ready, q)
+	case ready:
+		heap.Fix(&s.ready, q.index)
+	case q.index >= 0:
+		heap.Remove(&s.ready, q.index)
+	}
+	if len(q.tasks) == 0 && q.running == 0 {
+		delete(s.queues, q.key)
+	}
+}
+
+func (s *keyScheduler[I, O]) push(t task[I, O]) {
+	q := s.queue(t)
+	s.account(q)
+	if q.running >= s.limit {
+		s.counters.mu.Lock()
+		s.counters.stats.Held++
+		s.counters.mu.Unlock()
+	}
+	q.tasks = append(q.tasks, queuedTask[I, O]{t: t, at: time.Now(), seq: s.seq})
+	s.seq++
+	s.count++
+	s.update(q)
+}
+
+func (s *keyScheduler[I, O]) peek() (task[I, O], bool) {
+	if len(s.ready) == 0 {
+		return task[I, O]{}, false
+	}
+	return s.ready[0].tasks[0].t, true
+}
+
+func (s *keyScheduler[I, O]) pop() {
+	q := s.ready[0]
+	s.account(q)
+	q.tasks = q.tasks[1:]
+	q.running++
+	s.count--
+	if s.slots != nil {
+		s.slots.release(q.key)
+	}
+	s.update(q)
+}
+
+func (s *keyScheduler[I, O]) drain() []task[I, O] {
+	tasks := []task[I, O]{}
+	for _, q := range s.queues {
+		s.account(q)
+		for _, item := range q.tasks {
+			tasks = append(tasks, item.t)
+			if s.slots != nil {
+				s.slots.release(q.key)
+			}
+		}
+		q.tasks = nil
+		q.ready = 0
+		if q.index >= 0 {
+			heap.Remove(&s.ready, q.index)
+		}
+		if q.running == 0 {
+			delete(s.queues, q.key)
+		}
+	}
+	s.count = 0
+	s.readyCount = 0
+	return tasks
+}
+
+// completed records that a task handed to the workers has been processed, or given back, so that the next task with the same key
+// can be handed to the workers
+func (s *keyScheduler[I, O]) completed(t task[I, O]) {
+	q := s.queue(t)
+	s.account(q)
+	q.running--
+	s.update(q)
+}
+
+// keyOfFunc returns the key function of a keyed pool, checking that it takes inputs of type I
+func keyOfFunc[I any](keyOf any) func(I) any {
+	f, ok := keyOf.(func(I) any)
+	if !ok {
+		panic(fmt.Sprintf("the key function of the pool must take inputs of type %T", *new(I)))
+	}
+	return f
+}
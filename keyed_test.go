@@ -0,0 +1,300 @@
+package workerpool_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+type update struct {
+	account string
+	seq     int
+}
+
+// TestKeyedPool checks that the inputs with the same key are processed one at a time in the order they have been sent,
+// while the inputs with different keys are processed in parallel
+func TestKeyedPool(t *testing.T) {
+	var mu sync.Mutex
+	running := map[string]int{}
+	totalRunning := 0
+	maxTotalRunning := 0
+	processed := map[string][]int{}
+	do := func(u update) (update, error) {
+		mu.Lock()
+		running[u.account]++
+		if running[u.account] > 1 {
+			t.Errorf("Inputs of account %v processed concurrently", u.account)
+		}
+		totalRunning++
+		if totalRunning > maxTotalRunning {
+			maxTotalRunning = totalRunning
+		}
+		processed[u.account] = append(processed[u.account], u.seq)
+		mu.Unlock()
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		running[u.account]--
+		totalRunning--
+		mu.Unlock()
+		return u, nil
+	}
+	keyFn := func(u update) string {
+		return u.account
+	}
+	accounts := []string{"a", "b", "c", "d"}
+	numOfUpdatesPerAccount := 20
+	pool := workerpool.NewKeyed(4, keyFn, do, workerpool.WithQueueSize(10), workerpool.WithOutputBuffer(len(accounts)*numOfUpdatesPerAccount))
+	pool.Start(context.Background())
+	for i := 0; i < numOfUpdatesPerAccount; i++ {
+		for _, account := range accounts {
+			pool.Process(update{account: account, seq: i})
+		}
+	}
+	pool.Stop()
+
+	outputs := 0
+	for range pool.OutCh {
+		outputs++
+	}
+	if outputs != len(accounts)*numOfUpdatesPerAccount {
+		t.Errorf("Expected %v outputs - got %v", len(accounts)*numOfUpdatesPerAccount, outputs)
+	}
+	for _, account := range accounts {
+		for i, seq := range processed[account] {
+			if seq != i {
+				t.Fatalf("Expected the updates of account %v in the order they have been sent - got %v", account, processed[account])
+			}
+		}
+	}
+	if maxTotalRunning < 2 {
+		t.Errorf("Expected the inputs with different keys to be processed in parallel - got at most %v at the same time", maxTotalRunning)
+	}
+}
+
+// TestKeyedPoolSlowKey checks that the inputs of a slow key do not hold back the inputs of the other keys
+func TestKeyedPoolSlowKey(t *testing.T) {
+	release := make(chan struct{})
+	do := func(u update) (update, error) {
+		if u.account == "slow" {
+			<-release
+		}
+		return u, nil
+	}
+	pool := workerpool.NewKeyed(2, func(u update) string { return u.account }, do, workerpool.WithQueueSize(10))
+	pool.Start(context.Background())
+	pool.Process(update{account: "slow", seq: 0})
+	pool.Process(update{account: "slow", seq: 1})
+	for i := 0; i < 5; i++ {
+		pool.Process(update{account: "fast", seq: i})
+	}
+	for i := 0; i < 5; i++ {
+		select {
+		case u := <-pool.OutCh:
+			if u.account != "fast" {
+				t.Fatalf("Unexpected output %v", u)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("The inputs of a key have been held back by another key")
+		}
+	}
+	close(release)
+	go pool.Stop()
+	for range pool.OutCh {
+	}
+}
+
+// TestKeyedPoolDefaultQueue checks that, with the default size of the queue, an input held back does not hold back the inputs
+// of the other keys
+func TestKeyedPoolDefaultQueue(t *testing.T) {
+	release := make(chan struct{})
+	do := func(u update) (update, error) {
+		if u.account == "slow" {
+			<-release
+		}
+		return u, nil
+	}
+	pool := workerpool.NewKeyed(4, func(u update) string { return u.account }, do, workerpool.WithOutputBuffer(10))
+	pool.Start(context.Background())
+	pool.Process(update{account: "slow", seq: 0})
+	pool.Process(update{account: "slow", seq: 1})
+	pool.Process(update{account: "fast", seq: 0})
+	select {
+	case u := <-pool.OutCh:
+		if u.account != "fast" {
+			t.Fatalf("Unexpected output %v", u)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The inputs of a key have been held back by another key")
+	}
+	close(release)
+	pool.Stop()
+}
+
+// TestKeyedPoolAbort checks that the inputs waiting in the queue of a keyed pool are returned by Abort
+func TestKeyedPoolAbort(t *testing.T) {
+	do := func(ctx context.Context, in int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	pool := workerpool.NewKeyedWithContext(2, func(in int) int { return in % 2 }, do, workerpool.WithQueueSize(10))
+	pool.Start(context.Background())
+	for i := 0; i < 6; i++ {
+		pool.Process(i)
+	}
+	// the first input of each key is being processed, the others wait in the queue
+	deadline := time.Now().Add(time.Second)
+	for pool.QueueLen() != 4 || pool.Stats().Busy != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v inputs in the queue and %v being processed - got %v and %v", 4, 2, pool.QueueLen(), pool.Stats().Busy)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if unprocessed := pool.Abort(); len(unprocessed) != 4 {
+		t.Errorf("Expected %v inputs unprocessed - got %v", 4, unprocessed)
+	}
+}
+
+// TestPoolKeyLimit checks that at most n inputs with the same key are processed at the same time and that the inputs held back
+// are reported by the stats
+func TestPoolKeyLimit(t *testing.T) {
+	var mu sync.Mutex
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+	do := func(host string) (string, error) {
+		mu.Lock()
+		running[host]++
+		if running[host] > maxRunning[host] {
+			maxRunning[host] = running[host]
+		}
+		mu.Unlock()
+		if host == "slow" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		mu.Lock()
+		running[host]--
+		mu.Unlock()
+		return host, nil
+	}
+	limit := 2
+	pool := workerpool.New(6, do,
+		workerpool.WithQueueSize(20),
+		workerpool.WithOutputBuffer(40),
+		workerpool.WithKeyLimit(func(host string) string { return host }, limit),
+	)
+	pool.Start(context.Background())
+	for i := 0; i < 10; i++ {
+		pool.Process("slow")
+	}
+	for i := 0; i < 10; i++ {
+		pool.Process("fast")
+	}
+	// the inputs of the fast host are not held back behind the inputs of the slow host
+	for i := 0; i < 10; i++ {
+		select {
+		case host := <-pool.OutCh:
+			if host != "fast" {
+				t.Fatalf("Expected the output of the fast host to be emitted first - got %v", host)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("The inputs of a key have been held back by another key")
+		}
+	}
+	pool.Stop()
+	for range pool.OutCh {
+	}
+
+	if maxRunning["slow"] != limit {
+		t.Errorf("Expected at most %v inputs of the same key processed at the same time - got %v", limit, maxRunning["slow"])
+	}
+	stats := pool.Stats().KeyLimit
+	if stats.Held == 0 || stats.HeldTime <= 0 || stats.SaturatedKeys != 0 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+// TestPoolKeyLimitSmallQueue checks that the inputs held back because their key is saturated do not hold back the inputs
+// of the other keys even if they are more than the size of the queue
+func TestPoolKeyLimitSmallQueue(t *testing.T) {
+	release := make(chan struct{})
+	do := func(host string) (string, error) {
+		if host == "slow" {
+			<-release
+		}
+		return host, nil
+	}
+	pool := workerpool.New(4, do,
+		workerpool.WithQueueSize(1),
+		workerpool.WithOutputBuffer(20),
+		workerpool.WithKeyLimit(func(host string) string { return host }, 2),
+	)
+	pool.Start(context.Background())
+	// 2 inputs of the slow host are processed and 4 are held back
+	go func() {
+		for i := 0; i < 6; i++ {
+			pool.Process("slow")
+		}
+		for i := 0; i < 5; i++ {
+			pool.Process("fast")
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		select {
+		case host := <-pool.OutCh:
+			if host != "fast" {
+				t.Fatalf("Expected the output of the fast host to be emitted first - got %v", host)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("The inputs of a key have been held back by another key")
+		}
+	}
+	close(release)
+	pool.Stop()
+}
+
+// TestPoolKeyBacklog checks that a sender whose key has a full backlog waits, while the inputs of the other keys are accepted
+func TestPoolKeyBacklog(t *testing.T) {
+	release := make(chan struct{})
+	do := func(host string) (string, error) {
+		if host == "slow" {
+			<-release
+		}
+		return host, nil
+	}
+	pool := workerpool.NewKeyed(2, func(host string) string { return host }, do,
+		workerpool.WithOutputBuffer(10),
+		workerpool.WithKeyBacklog(1),
+	)
+	pool.Start(context.Background())
+	defer pool.Stop()
+	defer close(release)
+	pool.Process("slow")
+	pool.Process("slow")
+
+	if err := pool.ProcessTimeout("slow", 20*time.Millisecond); err != workerpool.ErrPoolFull {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrPoolFull, err)
+	}
+	if err := pool.ProcessTimeout("fast", time.Second); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	select {
+	case host := <-pool.OutCh:
+		if host != "fast" {
+			t.Fatalf("Unexpected output %v", host)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("The inputs of a key have been held back by another key")
+	}
+}
+
+// TestPoolKeyBacklogWithoutKeys checks that the constructor panics if the key backlog is set without a key limit
+func TestPoolKeyBacklogWithoutKeys(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic")
+		}
+	}()
+	workerpool.New(1, func(in int) (int, error) { return in, nil }, workerpool.WithKeyBacklog(1))
+}
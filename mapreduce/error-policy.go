@@ -0,0 +1,68 @@
+package mapreduce
+
+// Option configures the MapReduce functions
+type Option func(*options)
+
+type options struct {
+	errorPolicy     ErrorPolicy
+	maxStoredErrors int
+	errorMessage    func(ReduceError) string
+}
+
+func newOptions(opts []Option) options {
+	o := options{maxStoredErrors: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// ErrorPolicy decides how many errors a MapReduce function tolerates before cancelling the processing of the remaining inputs.
+// The zero value is CollectAll.
+type ErrorPolicy struct {
+	// maxErrors returns the number of errors tolerated when total inputs are processed, a negative number if there is no limit
+	maxErrors func(total int) int
+}
+
+// CollectAll processes all the inputs and returns all the errors occurred. It is the default policy.
+var CollectAll = ErrorPolicy{}
+
+// FailFast cancels the processing of the remaining inputs as soon as an error occurs
+var FailFast = MaxErrors(0)
+
+// MaxErrors tolerates up to n errors and cancels the processing of the remaining inputs when the error n+1 occurs
+func MaxErrors(n int) ErrorPolicy {
+	if n < 0 {
+		panic("the number of errors tolerated can not be negative")
+	}
+	return ErrorPolicy{maxErrors: func(int) int {
+		return n
+	}}
+}
+
+// MaxErrorRate tolerates errors for up to a fraction p of the input values and cancels the processing of the remaining inputs
+// when the errors exceed that fraction. p must be between 0 and 1.
+func MaxErrorRate(p float64) ErrorPolicy {
+	if p < 0 || p > 1 {
+		panic("the error rate must be between 0 and 1")
+	}
+	return ErrorPolicy{maxErrors: func(total int) int {
+		return int(p * float64(total))
+	}}
+}
+
+// limit returns the number of errors tolerated when total inputs are processed, a negative number if there is no limit
+func (p ErrorPolicy) limit(total int) int {
+	if p.maxErrors == nil {
+		return -1
+	}
+	return p.maxErrors(total)
+}
+
+// WithErrorPolicy sets the policy which decides when the processing is cancelled because of the errors occurred.
+// When the processing is cancelled, the ReduceError returned reports the number of inputs skipped.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(o *options) {
+		o.errorPolicy = policy
+	}
+}
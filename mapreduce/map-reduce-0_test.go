@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/EnricoPicci/workerpool"
 	"github.com/EnricoPicci/workerpool/mapreduce"
@@ -81,3 +82,59 @@ func TestReduce(t *testing.T) {
 		t.Errorf("Expected sum of the numbers received %v - got %v", expectedSum, gotSum)
 	}
 }
+
+// In this test the pool is paused while its results are being reduced and then resumed.
+// The test checks that Reduce keeps accumulating correctly across the pause.
+func TestReduceWithPause(t *testing.T) {
+	pool := workerpool.New(10, MapStringToInt)
+	pool.Start(context.Background())
+
+	numOfValuesToReduce := 10000
+	valuesToReduce := SliceOfIntegersAsStrings(numOfValuesToReduce)
+	go func() {
+		defer pool.Stop()
+		for i, v := range valuesToReduce {
+			if i == numOfValuesToReduce/2 {
+				pool.Pause()
+				time.Sleep(10 * time.Millisecond)
+				pool.Resume()
+			}
+			pool.Process(v)
+		}
+	}()
+
+	sum, err := mapreduce.Reduce(context.Background(), pool, SumNumbers, 0)
+
+	reduceErr := err.(mapreduce.ReduceError)
+	if len(reduceErr.Errors) != 1 {
+		t.Errorf("Expected number of errors %v - got %v", 1, len(reduceErr.Errors))
+	}
+	expectedSum := numOfValuesToReduce*(numOfValuesToReduce-1)/2 - NumGeneratingError
+	if expectedSum != sum {
+		t.Errorf("Expected sum %v - got %v", expectedSum, sum)
+	}
+}
+
+// In this test the errors are still buffered in ErrCh when the pool closes its channels.
+// The test checks that Reduce does not lose them.
+func TestReduceWithErrorBuffer(t *testing.T) {
+	numOfInputs := 20
+	processingError := errors.New("processing error")
+	pool := workerpool.New(4, func(in int) (int, error) { return 0, processingError }, workerpool.WithErrorBuffer(numOfInputs))
+	pool.Start(context.Background())
+	for i := 0; i < numOfInputs; i++ {
+		pool.Process(i)
+	}
+	// once stopped, OutCh and ErrCh are both closed while all the errors are still buffered in ErrCh
+	pool.Stop()
+
+	_, err := mapreduce.Reduce(context.Background(), pool, SumNumbers, 0)
+
+	reduceErr, ok := err.(mapreduce.ReduceError)
+	if !ok {
+		t.Fatalf("Expected a ReduceError - got %v", err)
+	}
+	if len(reduceErr.Errors) != numOfInputs {
+		t.Errorf("Expected number of errors %v - got %v", numOfInputs, len(reduceErr.Errors))
+	}
+}
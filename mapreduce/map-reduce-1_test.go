@@ -2,8 +2,15 @@ package mapreduce_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/EnricoPicci/workerpool"
 	"github.com/EnricoPicci/workerpool/mapreduce"
 )
 
@@ -95,3 +102,173 @@ func TestMapReduceAllErrorsPoolSize_1(t *testing.T) {
 		t.Errorf("Expected sum %v - got %v", expectedSum, gotSum)
 	}
 }
+
+// In this test the errors returned by MapReduce are inspected to find the inputs which failed
+func TestMapReduceFailedInputs(t *testing.T) {
+	valuesToReduce := []string{"1", "x", "3", "y"}
+
+	concurrent := 2
+	_, err := mapreduce.MapReduce(context.Background(), concurrent, valuesToReduce, mapStringToIntErr, SumNumbers, 0)
+
+	failed := mapreduce.FailedInputs[string](err)
+	sort.Strings(failed)
+	expectedFailed := []string{"x", "y"}
+	if !reflect.DeepEqual(expectedFailed, failed) {
+		t.Errorf("Expected failed inputs %v - got %v", expectedFailed, failed)
+	}
+	for _, e := range err.(mapreduce.ReduceError).Errors {
+		if !errors.Is(e, ConvError) {
+			t.Errorf("Expected error %v to wrap %v", e, ConvError)
+		}
+	}
+}
+
+// In this test the error returned by MapReduce is wrapped before looking for the inputs which failed
+func TestMapReduceFailedInputsWrapped(t *testing.T) {
+	valuesToReduce := []string{"1", "x", "3", "y"}
+
+	_, err := mapreduce.MapReduce(context.Background(), 2, valuesToReduce, mapStringToIntErr, SumNumbers, 0)
+	err = fmt.Errorf("summing the values: %w", err)
+
+	failed := mapreduce.FailedInputs[string](err)
+	sort.Strings(failed)
+	expectedFailed := []string{"x", "y"}
+	if !reflect.DeepEqual(expectedFailed, failed) {
+		t.Errorf("Expected failed inputs %v - got %v", expectedFailed, failed)
+	}
+}
+
+// In this test the mapper panics for one of the values and the test checks that MapReduce returns the panic as one of its errors
+func TestMapReducePanic(t *testing.T) {
+	mapper := func(input string) (int, error) {
+		n, err := MapStringToInt(input)
+		if n == 3 {
+			panic("cannot map 3")
+		}
+		return n, err
+	}
+	sum, err := mapreduce.MapReduce(context.Background(), 3, SliceOfIntegersAsStrings(10), mapper, SumNumbers, 0)
+
+	reduceErr := err.(mapreduce.ReduceError)
+	if len(reduceErr.Errors) != 2 {
+		t.Errorf("Expected number of errors %v - got %v", 2, len(reduceErr.Errors))
+	}
+	panics := 0
+	for _, e := range reduceErr.Errors {
+		var panicErr workerpool.PanicError[string]
+		if errors.As(e, &panicErr) {
+			panics++
+		}
+	}
+	if panics != 1 {
+		t.Errorf("Expected number of panics %v - got %v", 1, panics)
+	}
+	expectedSum := 10*9/2 - NumGeneratingError - 3
+	if expectedSum != sum {
+		t.Errorf("Expected sum %v - got %v", expectedSum, sum)
+	}
+}
+
+// In this test all the values generate errors and the FailFast policy cancels the processing at the first error
+func TestMapReduceFailFast(t *testing.T) {
+	numOfValuesToReduce := 1000
+	valuesToReduce := sliceOfEmptyStrings(numOfValuesToReduce)
+
+	_, err := mapreduce.MapReduce(context.Background(), 10, valuesToReduce, mapStringToIntErr, SumNumbers, 0,
+		mapreduce.WithErrorPolicy(mapreduce.FailFast))
+
+	if !errors.Is(err, ConvError) {
+		t.Fatalf("Expected error %v - got %v", ConvError, err)
+	}
+	reduceErr := err.(mapreduce.ReduceError)
+	if len(reduceErr.Errors) != 1 {
+		t.Errorf("Expected number of errors %v - got %v", 1, len(reduceErr.Errors))
+	}
+	if expected := numOfValuesToReduce - 1; reduceErr.Skipped != expected {
+		t.Errorf("Expected number of inputs skipped %v - got %v", expected, reduceErr.Skipped)
+	}
+}
+
+// In this test one value every 10 generates an error and the processing is cancelled when the errors exceed the budget
+func TestMapReduceErrorBudget(t *testing.T) {
+	numOfValuesToReduce := 1000
+	valuesToReduce := SliceOfIntegersAsStrings(numOfValuesToReduce)
+	for i := 0; i < numOfValuesToReduce; i += 10 {
+		valuesToReduce[i] = ""
+	}
+	policies := map[string]struct {
+		policy         mapreduce.ErrorPolicy
+		expectedErrors int
+	}{
+		"MaxErrors":    {mapreduce.MaxErrors(20), 21},
+		"MaxErrorRate": {mapreduce.MaxErrorRate(0.05), 51},
+		"CollectAll":   {mapreduce.CollectAll, 100},
+	}
+	for name, p := range policies {
+		t.Run(name, func(t *testing.T) {
+			_, err := mapreduce.MapReduce(context.Background(), 10, valuesToReduce, mapStringToIntErr, SumNumbers, 0,
+				mapreduce.WithErrorPolicy(p.policy))
+
+			reduceErr := err.(mapreduce.ReduceError)
+			if len(reduceErr.Errors) != p.expectedErrors {
+				t.Errorf("Expected number of errors %v - got %v", p.expectedErrors, len(reduceErr.Errors))
+			}
+			if p.expectedErrors == 100 && reduceErr.Skipped != 0 {
+				t.Errorf("Expected no input skipped - got %v", reduceErr.Skipped)
+			}
+			if p.expectedErrors < 100 && reduceErr.Skipped == 0 {
+				t.Error("Expected some inputs skipped")
+			}
+		})
+	}
+}
+
+// In this test the errors of two kinds are grouped, inspected with errors.Is and errors.As and only some of them are stored
+func TestMapReduceErrorGroups(t *testing.T) {
+	valuesToReduce := []string{"1", "", "x", "2", "", "y", ""}
+	mapper := func(s string) (int, error) {
+		if s == "" {
+			return 0, ConvError
+		}
+		return strconv.Atoi(s)
+	}
+
+	_, err := mapreduce.MapReduce(context.Background(), 3, valuesToReduce, mapper, SumNumbers, 0,
+		mapreduce.WithMaxStoredErrors(2), mapreduce.WithErrorMessage(mapreduce.DetailedErrorMessage))
+
+	if !errors.Is(err, ConvError) && !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Expected the error to wrap the errors of the mapper - got %v", err)
+	}
+	reduceErr := err.(mapreduce.ReduceError)
+	if len(reduceErr.Errors) != 2 || reduceErr.Overflow != 3 || reduceErr.Count() != 5 {
+		t.Errorf("Expected %v errors stored and %v overflowed - got %v and %v", 2, 3, len(reduceErr.Errors), reduceErr.Overflow)
+	}
+	counts := map[string]int{}
+	for _, g := range reduceErr.Groups {
+		counts[g.Type] += g.Count
+	}
+	if counts["*errors.errorString"] != 3 || counts["*strconv.NumError"] != 2 {
+		t.Errorf("Unexpected groups %+v", reduceErr.Groups)
+	}
+	if !strings.HasPrefix(err.Error(), "5 errors while reducing: ") || !strings.Contains(err.Error(), "3 x ") {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
+
+// In this test all the errors are stored, so errors.As finds the error of the mapper wrapped by each of them
+func TestMapReduceErrorsAs(t *testing.T) {
+	_, err := mapreduce.MapReduce(context.Background(), 2, []string{"a", "b"}, func(s string) (int, error) {
+		return strconv.Atoi(s)
+	}, SumNumbers, 0)
+
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Fatalf("Expected a *strconv.NumError - got %v", err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Expected the error to wrap %v", strconv.ErrSyntax)
+	}
+	if err.Error() != "2 errors while reducing" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
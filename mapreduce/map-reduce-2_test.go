@@ -65,3 +65,34 @@ func inputValues(numOfInputSentToPool int) []int {
 	}
 	return inputValues
 }
+
+// In this test a slice of integers is reduced to a slice of strings using the OrderedMapReduce function.
+// The reducer is not commutative, since it appends the results to a slice, and the test checks that the results are in the order of the input values
+func TestOrderedMapReduce(t *testing.T) {
+	numOfValuesToReduce := 10000
+	valuesToReduce := inputValues(numOfValuesToReduce)
+
+	concurrent := 100
+	window := 50
+	resultsReceived, err := mapreduce.OrderedMapReduce(context.Background(), concurrent, window, valuesToReduce, mapIntToString, reducer, []string{})
+
+	// check the results of the test
+	reduceErr := err.(mapreduce.ReduceError)
+	if len(reduceErr.Errors) != 1 {
+		t.Errorf("Expected number of errors %v - got %v", 1, len(reduceErr.Errors))
+	}
+	expectedNumOfResults := numOfValuesToReduce - 1
+	if expectedNumOfResults != len(resultsReceived) {
+		t.Fatalf("Expected number of results %v - got %v", expectedNumOfResults, len(resultsReceived))
+	}
+	expected := 0
+	for _, v := range resultsReceived {
+		if expected == numberGeneratingError {
+			expected++
+		}
+		if v != strconv.Itoa(expected) {
+			t.Fatalf("Expected result %v - got %v", expected, v)
+		}
+		expected++
+	}
+}
@@ -49,8 +49,11 @@ func TestMapReduceWithTimeoutPoolStatus(t *testing.T) {
 	_, err := reduce(ctx, pool, SumNumbers, accInitialValue)
 
 	// the context signal is triggered very soon in the test, so we wait for some time to give the pool the possibility to shut down all the workers
-	// and get to a stopped state
-	time.Sleep(time.Millisecond)
+	// and get to a stopped state. Since the pool is Stopped only once all its workers have exited, we poll its status.
+	deadline := time.Now().Add(time.Second)
+	for pool.GetStatus() != workerpool.Stopped && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
 
 	// check the results of the test
 	expectedError := context.DeadlineExceeded
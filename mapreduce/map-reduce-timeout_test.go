@@ -107,3 +107,59 @@ func TestMapReduceWithTimeoutWorkersRunning(t *testing.T) {
 		t.Errorf("MapReduce should have returned a context error - got %v", err)
 	}
 }
+
+// This test is the same as TestMapReduceWithTimeoutWorkersRunning but uses MapReduceWithContext, so the mapper
+// does not need to capture the context in a closure: it receives the context from the workerpool.
+func TestMapReduceWithContextTimeoutWorkersRunning(t *testing.T) {
+	workDuration := 10 * time.Millisecond
+	timeout := workDuration / 10
+	testDelay := workDuration * 10
+
+	mapperStarted := false
+	taskComplete := false
+
+	var testMu sync.Mutex
+
+	// a context with a timeout that is triggered with a not so short delay
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	mapper := func(mapperCtx context.Context, input int) (int, error) {
+		testMu.Lock()
+		mapperStarted = true
+		testMu.Unlock()
+		timer := time.NewTimer(workDuration)
+		select {
+		// we simulate the work of the worker with a timer
+		case <-timer.C: // timer fired, i.e. the worker has performed its task
+			testMu.Lock()
+			taskComplete = true
+			testMu.Unlock()
+			return input * 10, nil
+		case <-mapperCtx.Done(): // the timeout signal is received through the context passed by the workerpool
+			return 0, mapperCtx.Err()
+		}
+	}
+
+	concurrent := 4
+	// initial value of the accumulator to pass to the Reduce function
+	accInitialValue := 0
+	// Call MapReduceWithContext - since the context timeout is fired before the mapper is able to complete its mapping work, there should be a non nil error
+	_, err := mapreduce.MapReduceWithContext(ctx, concurrent, []int{0, 1, 2, 3, 4, 5, 6}, mapper, SumNumbers, accInitialValue)
+
+	// wait to make sure that, if the mappers have not been terminated by the context timeout, they have the time to set the taskComplete to true
+	time.Sleep(testDelay)
+
+	// check the results of the test
+	testMu.Lock()
+	if !mapperStarted {
+		t.Error("The mapper function has never started, i.e. the workerpool did not start its work")
+	}
+	if taskComplete {
+		t.Error("The mapper function was not terminated by the context timeout")
+	}
+	testMu.Unlock()
+	if err != ctx.Err() {
+		t.Errorf("MapReduceWithContext should have returned a context error - got %v", err)
+	}
+}
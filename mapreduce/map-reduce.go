@@ -4,6 +4,17 @@ A client can reduce the results sent by the pool into an accumulator using the f
 
 # MapReduce
 The MapReduce function implements the processing and the reduce operations in one function.
+The MapReduceWithContext function does the same with a mapper which receives a context that is cancelled when the processing has to be interrupted.
+The OrderedMapReduce function passes the results to the reducer in the order of the input values, so that it can be used with non commutative reducers.
+
+# Error policies
+By default the MapReduce functions process all the input values and collect all the errors. With the WithErrorPolicy option the processing
+of the remaining inputs is cancelled as soon as the first error occurs (FailFast), or when the errors exceed a number (MaxErrors) or a fraction
+of the input values (MaxErrorRate).
+
+# Errors
+The ReduceError returned wraps the errors occurred, so that errors.Is and errors.As can inspect them, and groups them by type and message.
+WithMaxStoredErrors limits the number of errors stored and WithErrorMessage sets the message of the error, e.g. DetailedErrorMessage.
 
 */
 
@@ -11,20 +22,13 @@ package mapreduce
 
 import (
 	"context"
-	"fmt"
 
 	"github.com/EnricoPicci/workerpool"
 )
 
-type ReduceError struct {
-	Errors []error
-}
-
-func (err ReduceError) Error() string {
-	return fmt.Sprintf("%v errors while reducing", len(err.Errors))
-}
-
-// Reduce the results returned by the processing of the pool into an accumulator. Returns the accumulator and a slice of errors, if errors occur
+// Reduce the results returned by the processing of the pool into an accumulator. Returns the accumulator and a slice of errors, if errors occur.
+// The pool can be created either with workerpool.New or with workerpool.NewWithContext.
+// If the pool emits results (see workerpool.WithResults), the errors are workerpool.TaskError values carrying the input which failed.
 func Reduce[I, O, R any](ctx context.Context, pool *workerpool.Pool[I, O], reducer func(R, O) R, initialValue R) (R, error) {
 	acc, err := reduce(ctx, pool, reducer, initialValue)
 
@@ -32,7 +36,8 @@ func Reduce[I, O, R any](ctx context.Context, pool *workerpool.Pool[I, O], reduc
 }
 
 // MapReduce process all the input values and returns a reduced result.
-// If errors occur, an error wrapping all the errors is returned.
+// If errors occur, an error wrapping all the errors is returned. Each error is a workerpool.TaskError carrying the input which failed.
+// The error policy set with the WithErrorPolicy option decides whether the processing is cancelled when errors occur.
 func MapReduce[I, O, R any](
 	ctx context.Context,
 	concurrent int,
@@ -40,55 +45,130 @@ func MapReduce[I, O, R any](
 	mapper func(I) (O, error),
 	reducer func(R, O) R,
 	initialValue R,
+	opts ...Option,
+) (R, error) {
+	pool := workerpool.New(concurrent, mapper, workerpool.WithResults())
+	return mapReduce(ctx, pool, inputValues, reducer, initialValue, opts)
+}
+
+// MapReduceWithContext process all the input values and returns a reduced result, like MapReduce.
+// The mapper receives a context which is cancelled when ctx is cancelled, so that a long running mapping can be interrupted.
+func MapReduceWithContext[I, O, R any](
+	ctx context.Context,
+	concurrent int,
+	inputValues []I,
+	mapper func(context.Context, I) (O, error),
+	reducer func(R, O) R,
+	initialValue R,
+	opts ...Option,
+) (R, error) {
+	pool := workerpool.NewWithContext(concurrent, mapper, workerpool.WithResults())
+	return mapReduce(ctx, pool, inputValues, reducer, initialValue, opts)
+}
+
+// OrderedMapReduce process all the input values and returns a reduced result, like MapReduce.
+// The results are passed to the reducer in the order of the input values, so the reducer does not need to be commutative,
+// e.g. it can concatenate strings or build an ordered slice.
+// window is the maximum number of results which can be held waiting for the results of the previous input values and must be greater than 0.
+func OrderedMapReduce[I, O, R any](
+	ctx context.Context,
+	concurrent int,
+	window int,
+	inputValues []I,
+	mapper func(I) (O, error),
+	reducer func(R, O) R,
+	initialValue R,
+	opts ...Option,
 ) (R, error) {
-	// create and start the pool
-	pool := workerpool.New(concurrent, mapper)
+	pool := workerpool.New(concurrent, mapper, workerpool.WithResults(), workerpool.WithOrdered(window))
+	return mapReduce(ctx, pool, inputValues, reducer, initialValue, opts)
+}
+
+func mapReduce[I, O, R any](ctx context.Context, pool *workerpool.Pool[I, O], inputValues []I, reducer func(R, O) R, initialValue R, opts []Option) (R, error) {
+	o := newOptions(opts)
+	// the pool is cancelled if the error policy stops the processing
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// start the pool
 	pool.Start(ctx)
 
 	// launch a goroutine that sends the input values to the pool. When all the values have been sent or the context signals, the pool is stopped
 	go func() {
 		defer pool.Stop()
 		for _, v := range inputValues {
-			pool.Process(v)
-			if ctx.Err() != nil {
+			if err := pool.ProcessContext(ctx, v); err != nil {
 				return
 			}
 		}
 	}()
 
-	acc, err := reduce(ctx, pool, reducer, initialValue)
+	acc, err := reduceResults(ctx, pool, reducer, initialValue, newErrorCollector(o), len(inputValues), o.errorPolicy.limit(len(inputValues)))
 
 	return acc, err
 }
 
 func reduce[I, O, R any](ctx context.Context, pool *workerpool.Pool[I, O], reducer func(R, O) R, acc R) (R, error) {
-	errors := []error{}
-	var err error
+	errors := newErrorCollector(newOptions(nil))
+	if pool.ResultCh != nil {
+		return reduceResults(ctx, pool, reducer, acc, errors, 0, -1)
+	}
 
-	for {
-		closed := false
+	// the pool closes OutCh before ErrCh, so the loop goes on until both are closed not to lose the errors still buffered in ErrCh
+	outCh, errCh := pool.OutCh, pool.ErrCh
+	for outCh != nil || errCh != nil {
 		select {
-		case res, more := <-pool.OutCh:
-			closed = !more
-			if closed {
-				break
+		case res, more := <-outCh:
+			if !more {
+				outCh = nil
+				continue
 			}
 			acc = reducer(acc, res)
-		case err, more := <-pool.ErrCh:
-			if more {
-				errors = append(errors, err)
+		case err, more := <-errCh:
+			if !more {
+				errCh = nil
+				continue
 			}
+			errors.add(err, err)
 		case <-ctx.Done():
 			return acc, ctx.Err()
 		}
-		if closed {
-			break
-		}
 	}
 
-	if len(errors) > 0 {
-		err = ReduceError{errors}
-	}
+	return acc, errors.err(0)
+}
 
-	return acc, err
+// reduceResults reduces the results emitted by a pool on its ResultCh.
+// If maxErrors is not negative, the reduction stops when the errors exceed maxErrors and the ReduceError returned reports
+// how many of the total inputs have not been reduced.
+func reduceResults[I, O, R any](
+	ctx context.Context,
+	pool *workerpool.Pool[I, O],
+	reducer func(R, O) R,
+	acc R,
+	errors *errorCollector,
+	total int,
+	maxErrors int,
+) (R, error) {
+	reduced := 0
+
+	for {
+		select {
+		case res, more := <-pool.ResultCh:
+			if !more {
+				return acc, errors.err(0)
+			}
+			reduced++
+			if res.Err != nil {
+				errors.add(res.TaskError(), res.Err)
+				if maxErrors >= 0 && errors.count() > maxErrors {
+					return acc, errors.err(total - reduced)
+				}
+				continue
+			}
+			acc = reducer(acc, res.Output)
+		case <-ctx.Done():
+			return acc, ctx.Err()
+		}
+	}
 }
@@ -10,10 +10,26 @@ func (values Reducable[I, O, R]) MapReduce(
 	reducer func(R, O) R,
 	seed R,
 	concurrent int,
+	opts ...Option,
 ) (R, error) {
 	if concurrent < 1 {
 		panic("concurrent must be greater than 0")
 	}
-	res, err := MapReduce(ctx, concurrent, values, mapper, reducer, seed)
+	res, err := MapReduce(ctx, concurrent, values, mapper, reducer, seed, opts...)
+	return res, err
+}
+
+func (values Reducable[I, O, R]) MapReduceWithContext(
+	ctx context.Context,
+	mapper func(context.Context, I) (O, error),
+	reducer func(R, O) R,
+	seed R,
+	concurrent int,
+	opts ...Option,
+) (R, error) {
+	if concurrent < 1 {
+		panic("concurrent must be greater than 0")
+	}
+	res, err := MapReduceWithContext(ctx, concurrent, values, mapper, reducer, seed, opts...)
 	return res, err
 }
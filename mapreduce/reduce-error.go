@@ -0,0 +1,150 @@
+package mapreduce
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// ReduceError is returned by the reduce and MapReduce functions when the processing of some inputs fails.
+// It wraps the errors occurred, so that errors.Is and errors.As can inspect them.
+type ReduceError struct {
+	Errors []error
+	// Skipped is the number of inputs whose results have not been reduced because the processing has been cancelled by the error policy
+	Skipped int
+	// Overflow is the number of errors which have not been stored in Errors because the limit set with WithMaxStoredErrors was reached
+	Overflow int
+	// Groups counts all the errors occurred, including the ones not stored, grouped by type and message, in the order they first occurred
+	Groups []ErrorGroup
+	// message, if not nil, builds the message of the error
+	message func(ReduceError) string
+}
+
+// ErrorGroup counts the errors with the same type and message
+type ErrorGroup struct {
+	Type    string
+	Message string
+	Count   int
+}
+
+// Count returns the number of errors occurred, including the ones not stored
+func (err ReduceError) Count() int {
+	return len(err.Errors) + err.Overflow
+}
+
+func (err ReduceError) Error() string {
+	if err.message != nil {
+		return err.message(err)
+	}
+	return summary(err)
+}
+
+// Unwrap returns the errors stored, which allows errors.Is and errors.As to find any of them
+func (err ReduceError) Unwrap() []error {
+	return err.Errors
+}
+
+// summary is the default message of a ReduceError, which reports the number of errors and of the inputs skipped
+func summary(err ReduceError) string {
+	if err.Skipped > 0 {
+		return fmt.Sprintf("%v errors while reducing, %v inputs skipped", err.Count(), err.Skipped)
+	}
+	return fmt.Sprintf("%v errors while reducing", err.Count())
+}
+
+// DetailedErrorMessage builds a message which reports, besides the number of errors, the groups of errors with their counts, e.g.
+//
+//	3 errors while reducing: 2 x "strconv.Atoi: parsing \"a\": invalid syntax" (*strconv.NumError), 1 x "timeout" (*errors.errorString)
+//
+// It can be passed to WithErrorMessage.
+func DetailedErrorMessage(err ReduceError) string {
+	groups := make([]string, len(err.Groups))
+	for i, g := range err.Groups {
+		groups[i] = fmt.Sprintf("%v x %q (%v)", g.Count, g.Message, g.Type)
+	}
+	return summary(err) + ": " + strings.Join(groups, ", ")
+}
+
+// WithErrorMessage sets the function which builds the message of the ReduceError returned, e.g. DetailedErrorMessage
+func WithErrorMessage(message func(ReduceError) string) Option {
+	return func(o *options) {
+		o.errorMessage = message
+	}
+}
+
+// WithMaxStoredErrors limits to n the number of errors stored in the ReduceError returned. The errors beyond the limit are only counted.
+// By default all the errors are stored.
+func WithMaxStoredErrors(n int) Option {
+	if n < 0 {
+		panic("the number of errors stored can not be negative")
+	}
+	return func(o *options) {
+		o.maxStoredErrors = n
+	}
+}
+
+// errorCollector collects the errors occurred while reducing
+type errorCollector struct {
+	// maxStored is the maximum number of errors stored, a negative number if there is no limit
+	maxStored int
+	message   func(ReduceError) string
+	errors    []error
+	overflow  int
+	groups    []ErrorGroup
+	// index maps the type and message of an error to its group
+	index map[[2]string]int
+}
+
+func newErrorCollector(o options) *errorCollector {
+	return &errorCollector{maxStored: o.maxStoredErrors, message: o.errorMessage, errors: []error{}, index: map[[2]string]int{}}
+}
+
+// add collects err. The errors are grouped by the type and message of cause, which is err itself or the error it wraps
+// if err is added only to carry the input which failed.
+func (c *errorCollector) add(err error, cause error) {
+	if c.maxStored < 0 || len(c.errors) < c.maxStored {
+		c.errors = append(c.errors, err)
+	} else {
+		c.overflow++
+	}
+	key := [2]string{fmt.Sprintf("%T", cause), cause.Error()}
+	i, ok := c.index[key]
+	if !ok {
+		i = len(c.groups)
+		c.index[key] = i
+		c.groups = append(c.groups, ErrorGroup{Type: key[0], Message: key[1]})
+	}
+	c.groups[i].Count++
+}
+
+// count returns the number of errors collected, including the ones not stored
+func (c *errorCollector) count() int {
+	return len(c.errors) + c.overflow
+}
+
+// err returns a ReduceError with the errors collected, or nil if no error has been collected
+func (c *errorCollector) err(skipped int) error {
+	if c.count() == 0 {
+		return nil
+	}
+	return ReduceError{Errors: c.errors, Skipped: skipped, Overflow: c.overflow, Groups: c.groups, message: c.message}
+}
+
+// FailedInputs returns the inputs whose processing failed, if err is, or wraps, a ReduceError whose errors carry their input,
+// which is the case when the pool used emits results (see workerpool.WithResults).
+// Errors which do not carry their input, and errors not stored because of the limit set with WithMaxStoredErrors, are ignored.
+func FailedInputs[I any](err error) []I {
+	var reduceErr ReduceError
+	if !errors.As(err, &reduceErr) {
+		return nil
+	}
+	inputs := []I{}
+	for _, e := range reduceErr.Errors {
+		if taskErr, ok := e.(workerpool.TaskError[I]); ok {
+			inputs = append(inputs, taskErr.Input)
+		}
+	}
+	return inputs
+}
@@ -0,0 +1,93 @@
+package workerpool
+
+import "time"
+
+// Option configures a Pool. Options are passed to New or NewWithContext, e.g.
+//
+//	pool := workerpool.New(size, do, workerpool.WithQueueSize(100), workerpool.WithOutputBuffer(10))
+type Option func(*options)
+
+// options collects the configuration set by the Option values passed to the constructors of the pool
+type options struct {
+	queueSize    int
+	outputBuffer int
+	errorBuffer  int
+	results      bool
+	ordered      bool
+	window       int
+	repanic      bool
+	retry        *RetryPolicy
+	breaker      *CircuitBreakerConfig
+	// deadLetter is a DeadLetter[I], where I is the type of the inputs of the pool, since options are not generic
+	deadLetter any
+	priorities *PriorityConfig
+	fair       *FairConfig
+	// keyOf is the key function, a func(I) any, of a pool created with WithKeyLimit, and keyLimit is the limit of each key
+	keyOf      any
+	keyLimit   int
+	keyBacklog int
+	// rateLimit is the rate limit of the pool and rateKeyOf, a func(I) any, the key function if the limit applies to each key
+	rateLimit *RateLimit
+	rateKeyOf any
+	adaptive  *AdaptiveConfig
+	// dedupKeyOf is the key function, a func(I) any, of a pool created with WithDedup and dedupWindow its dedup window
+	dedupKeyOf  any
+	dedupWindow time.Duration
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithQueueSize sets the number of values which can be sent to the pool and wait in a queue for a worker to be available.
+// By default the queue has no room, i.e. a value is accepted only when a worker is ready to take it.
+func WithQueueSize(n int) Option {
+	return func(o *options) {
+		o.queueSize = n
+	}
+}
+
+// WithOutputBuffer sets the size of the buffer of the channel OutCh, or of the channel ResultCh if the pool emits results.
+// By default the channels are unbuffered.
+func WithOutputBuffer(n int) Option {
+	return func(o *options) {
+		o.outputBuffer = n
+	}
+}
+
+// WithErrorBuffer sets the size of the buffer of the channel ErrCh. By default the channel is unbuffered.
+func WithErrorBuffer(n int) Option {
+	return func(o *options) {
+		o.errorBuffer = n
+	}
+}
+
+// WithResults makes the pool emit a Result for each input processed on the channel ResultCh.
+// With this option the channels OutCh and ErrCh are not used and are closed when the pool is stopped.
+func WithResults() Option {
+	return func(o *options) {
+		o.results = true
+	}
+}
+
+// WithOrdered makes the pool emit the results in the order the inputs have been sent to the pool, rather than in the order the workers complete them.
+// window is the maximum number of inputs which can be waiting for their result to be emitted and must be greater than 0.
+// When the window is full, Process blocks until the result of the oldest input is emitted.
+func WithOrdered(window int) Option {
+	return func(o *options) {
+		o.ordered = true
+		o.window = window
+	}
+}
+
+// WithRepanic makes the pool raise again, during Stop and on the goroutine which calls Stop, the first panic recovered while processing the inputs.
+// Without this option a panic is emitted as a PanicError like any other error.
+func WithRepanic() Option {
+	return func(o *options) {
+		o.repanic = true
+	}
+}
@@ -0,0 +1,51 @@
+package workerpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolWithQueueSize checks that, while the only worker is busy, the pool accepts as many values as the size of its queue
+// and that QueueLen reports the number of values waiting in the queue
+func TestPoolWithQueueSize(t *testing.T) {
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		<-release
+		return in, nil
+	}
+	queueSize := 5
+	pool := workerpool.New(1, do, workerpool.WithQueueSize(queueSize), workerpool.WithOutputBuffer(queueSize+1))
+	pool.Start(context.Background())
+
+	// wait for the worker to take the first value, so that the following ones are queued
+	pool.Process(0)
+	for pool.QueueLen() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	accepted := 0
+	for i := 1; i <= queueSize+1; i++ {
+		if pool.TryProcess(i) {
+			accepted++
+		}
+	}
+	if accepted != queueSize {
+		t.Errorf("Expected number of values accepted %v - got %v", queueSize, accepted)
+	}
+	if pool.QueueLen() != queueSize {
+		t.Errorf("Expected queue length %v - got %v", queueSize, pool.QueueLen())
+	}
+
+	// since the output channel has a buffer big enough, the pool can be stopped without reading the results
+	close(release)
+	pool.Stop()
+	results := 0
+	for range pool.OutCh {
+		results++
+	}
+	if results != queueSize+1 {
+		t.Errorf("Expected number of results %v - got %v", queueSize+1, results)
+	}
+}
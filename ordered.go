@@ -0,0 +1,125 @@
+package workerpool
+
+import (
+	"context"
+	"sort"
+)
+
+// sequencer reorders the results produced by the workers of a pool created with the WithOrdered option,
+// so that they are emitted in the order the inputs have been sent to the pool.
+// The number of inputs which have been sent to the pool and whose result has not been emitted yet is limited by the size of the window,
+// so that a slow input can not make the reorder buffer grow without limits.
+type sequencer[I, O any] struct {
+	// in receives the results from the workers
+	in chan Result[I, O]
+	// skipped receives the indexes of the inputs which have not been accepted by the pool and therefore will never have a result
+	skipped chan int
+	// tokens has the capacity of the window: a token is taken when an input is sent to the pool and is given back when its result is emitted
+	tokens chan struct{}
+	// done is closed when the sequencer has emitted all the results it has received
+	done chan struct{}
+}
+
+func newSequencer[I, O any](window int) *sequencer[I, O] {
+	if window < 1 {
+		panic("window must be greater than 0")
+	}
+	return &sequencer[I, O]{
+		in:      make(chan Result[I, O]),
+		skipped: make(chan int),
+		tokens:  make(chan struct{}, window),
+		done:    make(chan struct{}),
+	}
+}
+
+// acquire takes a place in the window, waiting for one to be available.
+// Returns an error if ctx or the context of the pool signals, or if the pool is stopping, before a place is available.
+// poolCtx is nil if the pool has not been started yet.
+func (s *sequencer[I, O]) acquire(ctx context.Context, poolCtx context.Context, stopping <-chan struct{}) error {
+	var poolDone <-chan struct{}
+	if poolCtx != nil {
+		poolDone = poolCtx.Done()
+	}
+	select {
+	case s.tokens <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-poolDone:
+		return poolCtx.Err()
+	case <-stopping:
+		return ErrPoolStopped
+	}
+}
+
+// tryAcquire takes a place in the window only if one is immediately available
+func (s *sequencer[I, O]) tryAcquire() bool {
+	select {
+	case s.tokens <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// skip tells the sequencer that the input with the given index will never have a result.
+// If the pool is stopping there is no need to tell it, since the sequencer emits all the results it holds when the pool stops.
+func (s *sequencer[I, O]) skip(poolDone <-chan struct{}, stopping <-chan struct{}, index int) {
+	select {
+	case s.skipped <- index:
+	case <-poolDone:
+	case <-stopping:
+	}
+}
+
+// run receives the results from the workers and emits them in the order of their index.
+// When the channel in is closed, the results still buffered are emitted in order, skipping the indexes of the results which have been lost
+// because the context has signalled.
+func (s *sequencer[I, O]) run(ctx context.Context, emit func(context.Context, Result[I, O]) bool) {
+	defer close(s.done)
+	pending := map[int]Result[I, O]{}
+	skipped := map[int]bool{}
+	next := 0
+	for {
+		select {
+		case i := <-s.skipped:
+			skipped[i] = true
+		case res, more := <-s.in:
+			if !more {
+				indexes := make([]int, 0, len(pending))
+				for i := range pending {
+					indexes = append(indexes, i)
+				}
+				sort.Ints(indexes)
+				for _, i := range indexes {
+					if !emit(ctx, pending[i]) {
+						return
+					}
+					<-s.tokens
+				}
+				return
+			}
+			pending[res.Index] = res
+		case <-ctx.Done():
+			return
+		}
+		for {
+			if skipped[next] {
+				delete(skipped, next)
+				<-s.tokens
+				next++
+				continue
+			}
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if !emit(ctx, r) {
+				return
+			}
+			<-s.tokens
+			next++
+		}
+	}
+}
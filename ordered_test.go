@@ -0,0 +1,87 @@
+package workerpool_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolOrdered creates an ordered pool whose workers take a random time to process each input
+// and checks that the results are received in the same order the inputs have been sent
+func TestPoolOrdered(t *testing.T) {
+	do := func(in int) (string, error) {
+		time.Sleep(time.Duration(rand.Intn(100)) * time.Microsecond)
+		return fmt.Sprintf("%v", in), nil
+	}
+	window := 5
+	pool := workerpool.New(20, do, workerpool.WithOrdered(window))
+	pool.Start(context.Background())
+
+	numOfInputSentToPool := 2000
+	go func() {
+		defer pool.Stop()
+		for i := 0; i < numOfInputSentToPool; i++ {
+			pool.Process(i)
+		}
+	}()
+
+	resultsReceived := []string{}
+	for res := range pool.OutCh {
+		resultsReceived = append(resultsReceived, res)
+	}
+
+	if len(resultsReceived) != numOfInputSentToPool {
+		t.Fatalf("Expected number of results %v - got %v", numOfInputSentToPool, len(resultsReceived))
+	}
+	for i, res := range resultsReceived {
+		if res != fmt.Sprintf("%v", i) {
+			t.Fatalf("Expected result %v in position %v - got %v", i, i, res)
+		}
+	}
+}
+
+// TestPoolOrderedWindow checks that, while the first input is being processed, no more than window inputs can be sent to an ordered pool
+func TestPoolOrderedWindow(t *testing.T) {
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		if in == 0 {
+			// the first input is slow and holds the emission of all the others
+			<-release
+		}
+		return in, nil
+	}
+	window := 3
+	pool := workerpool.New(10, do, workerpool.WithOrdered(window))
+	pool.Start(context.Background())
+
+	sent := make(chan int, 100)
+	go func() {
+		defer pool.Stop()
+		for i := 0; i < 10; i++ {
+			pool.Process(i)
+			sent <- i
+		}
+	}()
+
+	// give the producer the time to fill the window
+	time.Sleep(10 * time.Millisecond)
+	if len(sent) != window {
+		t.Errorf("Expected number of inputs accepted by the pool while the first one is being processed %v - got %v", window, len(sent))
+	}
+	close(release)
+
+	next := 0
+	for res := range pool.OutCh {
+		if res != next {
+			t.Errorf("Expected result %v - got %v", next, res)
+		}
+		next++
+	}
+	if next != 10 {
+		t.Errorf("Expected number of results %v - got %v", 10, next)
+	}
+}
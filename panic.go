@@ -0,0 +1,28 @@
+package workerpool
+
+import (
+	"fmt"
+)
+
+// PanicError is the error produced when the processing of an input panics.
+// It carries the value recovered from the panic, the stack trace of the goroutine which panicked and the input being processed.
+type PanicError[I any] struct {
+	Value any
+	Stack []byte
+	Input I
+}
+
+func (err PanicError[I]) Error() string {
+	return fmt.Sprintf("panic while processing input %v: %v", err.Input, err.Value)
+}
+
+// Unwrap returns the value recovered from the panic if it is an error, so that errors.Is and errors.As can inspect it
+func (err PanicError[I]) Unwrap() error {
+	if e, ok := err.Value.(error); ok {
+		return e
+	}
+	return nil
+}
+
+// isPanic marks PanicError, whatever its type parameter, so that it can be recognized without knowing the type of the input
+func (err PanicError[I]) isPanic() {}
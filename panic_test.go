@@ -0,0 +1,111 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolPanic creates a pool with 1 worker whose function panics for a certain input
+// and checks that the panic is received as a PanicError on the error channel and that the worker keeps processing the other inputs
+func TestPoolPanic(t *testing.T) {
+	numberGeneratingPanic := 3
+	do := func(in int) (string, error) {
+		if in == numberGeneratingPanic {
+			panic("something went wrong")
+		}
+		return fmt.Sprintf("%v", in), nil
+	}
+	pool := workerpool.New(1, do)
+	pool.Start(context.Background())
+
+	numOfInputSentToPool := 10
+	go func() {
+		defer pool.Stop()
+		for i := 0; i < numOfInputSentToPool; i++ {
+			pool.Process(i)
+		}
+	}()
+
+	resultsReceived := []string{}
+	errorsReceived := []error{}
+	for outCh, errCh := pool.OutCh, pool.ErrCh; outCh != nil || errCh != nil; {
+		select {
+		case res, more := <-outCh:
+			if !more {
+				outCh = nil
+				continue
+			}
+			resultsReceived = append(resultsReceived, res)
+		case err, more := <-errCh:
+			if !more {
+				errCh = nil
+				continue
+			}
+			errorsReceived = append(errorsReceived, err)
+		}
+	}
+
+	if len(resultsReceived) != numOfInputSentToPool-1 {
+		t.Errorf("Expected number of results %v - got %v", numOfInputSentToPool-1, len(resultsReceived))
+	}
+	if len(errorsReceived) != 1 {
+		t.Fatalf("Expected number of errors %v - got %v", 1, len(errorsReceived))
+	}
+	var panicErr workerpool.PanicError[int]
+	if !errors.As(errorsReceived[0], &panicErr) {
+		t.Fatalf("Expected a PanicError - got %T", errorsReceived[0])
+	}
+	if panicErr.Input != numberGeneratingPanic {
+		t.Errorf("Expected the input of the panic to be %v - got %v", numberGeneratingPanic, panicErr.Input)
+	}
+	if panicErr.Value != "something went wrong" {
+		t.Errorf("Expected the value recovered to be %q - got %v", "something went wrong", panicErr.Value)
+	}
+	if len(panicErr.Stack) == 0 {
+		t.Error("Expected the PanicError to carry the stack trace")
+	}
+}
+
+// TestPoolRepanic checks that a pool created with the WithRepanic option raises the panic again when it is stopped
+func TestPoolRepanic(t *testing.T) {
+	do := func(in int) (int, error) {
+		if in == 1 {
+			panic(in)
+		}
+		return in, nil
+	}
+	pool := workerpool.New(2, do, workerpool.WithRepanic())
+	pool.Start(context.Background())
+
+	go func() {
+		for range pool.OutCh {
+		}
+	}()
+	go func() {
+		for err := range pool.ErrCh {
+			t.Errorf("No error expected since the panic is raised again by Stop - got %v", err)
+		}
+	}()
+	for i := 0; i < 5; i++ {
+		pool.Process(i)
+	}
+
+	defer func() {
+		v := recover()
+		panicErr, ok := v.(workerpool.PanicError[int])
+		if !ok {
+			t.Fatalf("Expected Stop to panic with a PanicError - got %v", v)
+		}
+		if panicErr.Input != 1 {
+			t.Errorf("Expected the input of the panic to be %v - got %v", 1, panicErr.Input)
+		}
+		if pool.GetStatus() != workerpool.Failed {
+			t.Errorf("Expected pool status %v - got %v", workerpool.Failed, pool.GetStatus())
+		}
+	}()
+	pool.Stop()
+}
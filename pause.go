@@ -0,0 +1,61 @@
+package workerpool
+
+import "context"
+
+// Pause stops the workers from taking new values from the queue. The values being processed complete their processing
+// and the values in the queue wait until Resume is called. Values can still be sent to a paused pool, as long as the queue has room.
+// Returns an error if the pool is not running.
+func (pool *Pool[I, O]) Pause() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	// a pool paused by its circuit breaker stays paused, even when the circuit is half open, until Resume is called
+	if pool.status == Paused && pool.pausedByCircuit {
+		pool.pausedByCircuit = false
+		return nil
+	}
+	if err := pool.transition(Paused); err != nil {
+		return err
+	}
+	pool.paused = make(chan struct{})
+	return nil
+}
+
+// Resume lets the workers of a paused pool take values from the queue again.
+// Returns an error if the pool is not paused.
+func (pool *Pool[I, O]) Resume() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if err := pool.transition(Running); err != nil {
+		return err
+	}
+	pool.openGate()
+	return nil
+}
+
+// openGate releases the workers waiting for the pool to be resumed. It must be called holding the lock of the pool.
+func (pool *Pool[I, O]) openGate() {
+	pool.pausedByCircuit = false
+	if pool.paused != nil {
+		close(pool.paused)
+		pool.paused = nil
+	}
+}
+
+// waitIfPaused blocks the worker while the pool is paused.
+// Returns false if the context signals while waiting, or if quit is closed, i.e. the worker is retired, while waiting.
+func (pool *Pool[I, O]) waitIfPaused(ctx context.Context, quit <-chan struct{}) bool {
+	pool.mu.Lock()
+	paused := pool.paused
+	pool.mu.Unlock()
+	if paused == nil {
+		return true
+	}
+	select {
+	case <-paused:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-quit:
+		return false
+	}
+}
@@ -0,0 +1,73 @@
+package workerpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolPause pauses a pool, sends some values to it and checks that none is processed until the pool is resumed
+func TestPoolPause(t *testing.T) {
+	do := func(in int) (int, error) {
+		return in, nil
+	}
+	numOfInputSentToPool := 10
+	pool := workerpool.New(3, do, workerpool.WithQueueSize(numOfInputSentToPool), workerpool.WithOutputBuffer(numOfInputSentToPool))
+	if err := pool.Pause(); err == nil {
+		t.Error("Expected an error pausing a pool which has not been started")
+	}
+	pool.Start(context.Background())
+
+	if err := pool.Pause(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if pool.GetStatus() != workerpool.Paused {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Paused, pool.GetStatus())
+	}
+	for i := 0; i < numOfInputSentToPool; i++ {
+		if err := pool.ProcessContext(context.Background(), i); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if processed := pool.Stats().Processed; processed != 0 {
+		t.Errorf("Expected no value processed while the pool is paused - got %v", processed)
+	}
+
+	if err := pool.Resume(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := pool.Resume(); err == nil {
+		t.Error("Expected an error resuming a pool which is running")
+	}
+	if pool.GetStatus() != workerpool.Running {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Running, pool.GetStatus())
+	}
+	pool.Stop()
+	results := 0
+	for range pool.OutCh {
+		results++
+	}
+	if results != numOfInputSentToPool {
+		t.Errorf("Expected number of results %v - got %v", numOfInputSentToPool, results)
+	}
+}
+
+// TestPoolStopWhilePaused checks that a paused pool which is stopped processes the values in its queue
+func TestPoolStopWhilePaused(t *testing.T) {
+	do := func(in int) (int, error) {
+		return in, nil
+	}
+	pool := workerpool.New(1, do, workerpool.WithQueueSize(5), workerpool.WithOutputBuffer(5))
+	pool.Start(context.Background())
+	pool.Pause()
+	for i := 0; i < 5; i++ {
+		pool.Process(i)
+	}
+	pool.Stop()
+	if processed := pool.Stats().Processed; processed != 5 {
+		t.Errorf("Expected number of values processed %v - got %v", 5, processed)
+	}
+}
@@ -0,0 +1,183 @@
+package workerpool
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// PriorityConfig configures the priority queue of a pool
+type PriorityConfig struct {
+	// Levels is the number of priorities, from 0, the lowest, to Levels-1, the highest. It must be greater than 0.
+	Levels int
+	// Default is the priority of the inputs sent without a priority, e.g. with Process. It must be between 0 and Levels-1.
+	Default int
+	// Aging, if greater than 0, raises the priority of an input by one level for each Aging interval it waits in the queue,
+	// so that the inputs with low priority are not starved by a flood of inputs with high priority.
+	Aging time.Duration
+}
+
+// PriorityStats is a snapshot of the activity of the pool for the inputs with one priority
+type PriorityStats struct {
+	// Queued is the number of inputs waiting in the queue
+	Queued int
+	// Processed is the number of inputs whose processing has completed, successfully or not, and Failed the number of those which failed
+	Processed int64
+	Failed    int64
+	// WaitTime is the total time the inputs have waited in the queue before being taken by a worker
+	WaitTime time.Duration
+}
+
+// WithPriorities makes the pool take the inputs waiting in its queue in order of priority, rather than in the order they have been sent.
+// The inputs with the same priority are taken in the order they have been sent.
+// The size of the queue is set with WithQueueSize and is at least 1: the priorities are effective among the inputs waiting in the queue.
+func WithPriorities(config PriorityConfig) Option {
+	if config.Levels < 1 {
+		panic("the number of priority levels must be greater than 0")
+	}
+	if config.Default < 0 || config.Default >= config.Levels {
+		panic("the default priority must be between 0 and the number of levels minus 1")
+	}
+	return func(o *options) {
+		o.priorities = &config
+	}
+}
+
+// ProcessWithPriority sends one value to the pool, like Process, with a priority between 0, the lowest, and the number of levels
+// of the pool minus 1. The priority is ignored if the pool is not created with the WithPriorities option.
+func (pool *Pool[I, O]) ProcessWithPriority(input I, priority int) {
+	if levels := pool.options.priorities; levels != nil && (priority < 0 || priority >= levels.Levels) {
+		panic("the priority must be between 0 and the number of levels minus 1")
+	}
+	t := task[I, O]{input: input, priority: priority}
+	pool.send(context.Background(), t, true, false)
+}
+
+// newTask returns a task for input with the default priority
+func (pool *Pool[I, O]) newTask(input I) task[I, O] {
+	t := task[I, O]{input: input}
+	if pool.options.priorities != nil {
+		t.priority = pool.options.priorities.Default
+	}
+	return t
+}
+
+// PriorityStats returns a snapshot of the activity of the pool for each priority, nil if the pool has no priorities
+func (pool *Pool[I, O]) PriorityStats() []PriorityStats {
+	if pool.priorityCounters == nil {
+		return nil
+	}
+	return pool.priorityCounters.snapshot()
+}
+
+// priorityCounters are the counters of the activity for each priority, which survive the reset of the pool
+type priorityCounters struct {
+	mu     sync.Mutex
+	levels []PriorityStats
+}
+
+func (c *priorityCounters) snapshot() []PriorityStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]PriorityStats{}, c.levels...)
+}
+
+// completed records that the processing of an input with priority has completed
+func (c *priorityCounters) completed(priority int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.levels[priority].Processed++
+	if err != nil {
+		c.levels[priority].Failed++
+	}
+}
+
+// queuedTask is a task waiting in the queue of a pool with priorities or fair scheduling
+type queuedTask[I, O any] struct {
+	t  task[I, O]
+	at time.Time
+	// key orders the tasks in the queue, the lowest first, and seq orders the tasks with the same key in the order they have been sent
+	key int64
+	seq uint64
+}
+
+// taskHeap implements heap.Interface
+type taskHeap[I, O any] []queuedTask[I, O]
+
+func (h taskHeap[I, O]) Len() int { return len(h) }
+func (h taskHeap[I, O]) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key < h[j].key
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap[I, O]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap[I, O]) Push(x any)   { *h = append(*h, x.(queuedTask[I, O])) }
+func (h *taskHeap[I, O]) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// priorityScheduler is the scheduler which hands the tasks in order of priority
+type priorityScheduler[I, O any] struct {
+	config   PriorityConfig
+	tasks    taskHeap[I, O]
+	seq      uint64
+	counters *priorityCounters
+}
+
+func newPriorityScheduler[I, O any](config PriorityConfig, counters *priorityCounters) *priorityScheduler[I, O] {
+	return &priorityScheduler[I, O]{config: config, counters: counters}
+}
+
+func (s *priorityScheduler[I, O]) len() int {
+	return len(s.tasks)
+}
+
+// push puts a task in the queue. With aging, the key of a task is the time it has been queued less one Aging interval for each level
+// of priority, so that a task waiting for one Aging interval precedes the tasks with one level more sent after it.
+func (s *priorityScheduler[I, O]) push(t task[I, O]) {
+	now := time.Now()
+	key := -int64(t.priority)
+	if s.config.Aging > 0 {
+		key = now.UnixNano() - int64(t.priority)*int64(s.config.Aging)
+	}
+	heap.Push(&s.tasks, queuedTask[I, O]{t: t, at: now, key: key, seq: s.seq})
+	s.seq++
+	s.counters.mu.Lock()
+	s.counters.levels[t.priority].Queued++
+	s.counters.mu.Unlock()
+}
+
+// peek returns the task with the highest priority, if any
+func (s *priorityScheduler[I, O]) peek() (task[I, O], bool) {
+	if len(s.tasks) == 0 {
+		return task[I, O]{}, false
+	}
+	return s.tasks[0].t, true
+}
+
+// pop removes the task with the highest priority, which has been handed to a worker
+func (s *priorityScheduler[I, O]) pop() {
+	item := heap.Pop(&s.tasks).(queuedTask[I, O])
+	s.counters.mu.Lock()
+	s.counters.levels[item.t.priority].Queued--
+	s.counters.levels[item.t.priority].WaitTime += time.Since(item.at)
+	s.counters.mu.Unlock()
+}
+
+func (s *priorityScheduler[I, O]) drain() []task[I, O] {
+	tasks := make([]task[I, O], len(s.tasks))
+	s.counters.mu.Lock()
+	for i, item := range s.tasks {
+		tasks[i] = item.t
+		s.counters.levels[item.t.priority].Queued--
+	}
+	s.counters.mu.Unlock()
+	s.tasks = nil
+	return tasks
+}
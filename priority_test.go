@@ -0,0 +1,131 @@
+package workerpool_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// blockedPool returns a pool with one worker which is kept busy processing the input -1 until release is closed,
+// so that the inputs sent afterwards wait in the queue
+func blockedPool(t *testing.T, opts ...workerpool.Option) (*workerpool.Pool[int, int], chan struct{}) {
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		if in == -1 {
+			<-release
+		}
+		return in, nil
+	}
+	pool := workerpool.New(1, do, opts...)
+	pool.Start(context.Background())
+	pool.Process(-1)
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Busy != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("The worker has not started processing the first input")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return pool, release
+}
+
+// TestPoolPriorities checks that the inputs with higher priority are processed first and that the inputs with the same priority
+// are processed in the order they have been sent
+func TestPoolPriorities(t *testing.T) {
+	pool, release := blockedPool(t,
+		workerpool.WithQueueSize(10),
+		workerpool.WithOutputBuffer(20),
+		workerpool.WithPriorities(workerpool.PriorityConfig{Levels: 3, Default: 1}),
+	)
+	for i := 0; i < 3; i++ {
+		pool.ProcessWithPriority(i, 0)
+		pool.Process(10 + i)
+		pool.ProcessWithPriority(20+i, 2)
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.QueueLen() != 9 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v inputs in the queue - got %v", 9, pool.QueueLen())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	pool.Stop()
+
+	outputs := []int{}
+	for out := range pool.OutCh {
+		outputs = append(outputs, out)
+	}
+	expected := []int{-1, 20, 21, 22, 10, 11, 12, 0, 1, 2}
+	if len(outputs) != len(expected) {
+		t.Fatalf("Expected outputs %v - got %v", expected, outputs)
+	}
+	for i := range expected {
+		if outputs[i] != expected[i] {
+			t.Fatalf("Expected outputs %v - got %v", expected, outputs)
+		}
+	}
+
+	stats := pool.Stats().Priorities
+	if len(stats) != 3 {
+		t.Fatalf("Expected stats for %v priorities - got %v", 3, len(stats))
+	}
+	// the first input has been sent with the default priority
+	expectedProcessed := []int64{3, 4, 3}
+	for p, s := range stats {
+		if s.Processed != expectedProcessed[p] || s.Queued != 0 || s.WaitTime <= 0 {
+			t.Errorf("Unexpected stats for priority %v: %+v", p, s)
+		}
+	}
+}
+
+// TestPoolPriorityAging checks that an input with low priority which has waited long enough is processed before an input with
+// higher priority sent after it
+func TestPoolPriorityAging(t *testing.T) {
+	pool, release := blockedPool(t,
+		workerpool.WithQueueSize(10),
+		workerpool.WithOutputBuffer(20),
+		workerpool.WithPriorities(workerpool.PriorityConfig{Levels: 3, Aging: time.Millisecond}),
+	)
+	pool.ProcessWithPriority(0, 0)
+	time.Sleep(20 * time.Millisecond)
+	pool.ProcessWithPriority(2, 2)
+	close(release)
+	pool.Stop()
+
+	outputs := []int{}
+	for out := range pool.OutCh {
+		outputs = append(outputs, out)
+	}
+	if len(outputs) != 3 || outputs[1] != 0 || outputs[2] != 2 {
+		t.Errorf("Expected the aged input to be processed first - got %v", outputs)
+	}
+}
+
+// TestPoolPriorityAbort checks that the inputs waiting in the priority queue are returned by Abort
+func TestPoolPriorityAbort(t *testing.T) {
+	do := func(ctx context.Context, in int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	pool := workerpool.NewWithContext(1, do,
+		workerpool.WithQueueSize(10),
+		workerpool.WithPriorities(workerpool.PriorityConfig{Levels: 2}),
+	)
+	pool.Start(context.Background())
+	for i := 0; i < 5; i++ {
+		pool.ProcessWithPriority(i, i%2)
+	}
+	unprocessed := pool.Abort()
+	sort.Ints(unprocessed)
+	// one of the inputs may have been taken by the worker, which has been interrupted
+	if len(unprocessed) < 4 {
+		t.Errorf("Expected at least %v inputs unprocessed - got %v", 4, unprocessed)
+	}
+	if pool.GetStatus() != workerpool.Aborted {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Aborted, pool.GetStatus())
+	}
+}
@@ -0,0 +1,172 @@
+package workerpool
+
+import (
+	"context"
+	"time"
+)
+
+// Process sends one value to the pool to be processed by the first available worker.
+// If the context of the pool is cancelled, the value is discarded since there are no more workers to process it.
+// If the pool is stopped, the value is discarded. Use ProcessContext to know whether the value has been accepted.
+// If the pool is ordered and its window is full, Process waits for the result of the oldest input to be emitted.
+func (pool *Pool[I, O]) Process(input I) {
+	t := pool.newTask(input)
+	pool.send(context.Background(), t, true, false)
+}
+
+// TryProcess sends one value to the pool only if a worker is immediately available to take it.
+// Returns true if the value has been accepted.
+func (pool *Pool[I, O]) TryProcess(input I) bool {
+	t := pool.newTask(input)
+	return pool.send(context.Background(), t, false, true) == nil
+}
+
+// ProcessContext sends one value to the pool, waiting for a worker to be available until ctx signals.
+// Returns ErrPoolNotStarted if the pool has not been started, ErrPoolStopped if the pool is stopped, the error of ctx if ctx signals
+// before the value is accepted and the error of the context of the pool if the pool context signals.
+func (pool *Pool[I, O]) ProcessContext(ctx context.Context, input I) error {
+	t := pool.newTask(input)
+	return pool.send(ctx, t, true, true)
+}
+
+// ProcessTimeout sends one value to the pool, waiting at most for timeout for a worker to be available.
+// Returns ErrPoolFull if no worker is available within timeout. The other errors are the same returned by ProcessContext.
+func (pool *Pool[I, O]) ProcessTimeout(input I, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := pool.ProcessContext(ctx, input)
+	if err == context.DeadlineExceeded {
+		return ErrPoolFull
+	}
+	return err
+}
+
+// Submit sends one value to the pool to be processed by the first available worker and returns a Future which receives the result.
+// The result is not sent to the pool channels. ctx bounds the wait for a worker to be available and, once the processing has started,
+// its cancellation cancels the context passed to the function of the pool.
+// If the value can not be sent to the pool, the Future is completed with the error which explains why, as returned by ProcessContext.
+// If the pool is created with WithDedup, see WithDedup for how the values with the same key are coalesced.
+func (pool *Pool[I, O]) Submit(ctx context.Context, input I) *Future[O] {
+	if pool.dedup != nil {
+		return pool.submitDedup(ctx, input)
+	}
+	f := newFuture[O]()
+	t := pool.newTask(input)
+	t.index, t.future, t.ctx = -1, f, ctx
+	if err := pool.send(ctx, t, true, false); err != nil {
+		var zero O
+		f.complete(zero, err)
+	}
+	return f
+}
+
+// send sends a task to the workers.
+// If wait is false, send returns ErrPoolFull if no worker is immediately available, otherwise it waits until ctx signals.
+// If mustBeStarted is true, send returns ErrPoolNotStarted if the pool has not been started, otherwise it waits for the pool to be started.
+// The index of the task is assigned by send, unless the task has been submitted with a Future.
+func (pool *Pool[I, O]) send(ctx context.Context, t task[I, O], wait bool, mustBeStarted bool) error {
+	pool.mu.Lock()
+	if !pool.status.accepting() {
+		pool.mu.Unlock()
+		return ErrPoolStopped
+	}
+	if pool.status == Created && mustBeStarted {
+		pool.mu.Unlock()
+		return ErrPoolNotStarted
+	}
+	pool.senders++
+	poolCtx := pool.ctx
+	pool.mu.Unlock()
+	defer func() {
+		pool.mu.Lock()
+		pool.senders--
+		if pool.senders == 0 {
+			pool.sendersDone.Broadcast()
+		}
+		pool.mu.Unlock()
+	}()
+	// poolDone is nil, i.e. it never signals, until the pool is started
+	var poolDone <-chan struct{}
+	if poolCtx != nil {
+		poolDone = poolCtx.Done()
+	}
+
+	if pool.tenants != nil {
+		if err := pool.tenants.acquire(ctx, poolCtx, pool.stopping, t.tenant, wait); err != nil {
+			return err
+		}
+	}
+	if pool.keyOf != nil {
+		t.key = pool.keyOf(t.input)
+	}
+	if pool.keySlots != nil {
+		if err := pool.keySlots.acquire(ctx, poolCtx, pool.stopping, t.key, wait); err != nil {
+			return err
+		}
+	}
+	// releaseSlot frees the slot of the tenant, or of the key, in the queue if the task is not sent
+	releaseSlot := func() {
+		if pool.tenants != nil {
+			pool.tenants.mu.Lock()
+			pool.tenants.release(t.tenant)
+			pool.tenants.mu.Unlock()
+		}
+		if pool.keySlots != nil {
+			pool.keySlots.release(t.key)
+		}
+	}
+	ordered := pool.ordered != nil && t.future == nil
+	if ordered {
+		if !wait {
+			if !pool.ordered.tryAcquire() {
+				releaseSlot()
+				return ErrPoolFull
+			}
+		} else if err := pool.ordered.acquire(ctx, poolCtx, pool.stopping); err != nil {
+			releaseSlot()
+			return err
+		}
+	}
+	if t.future == nil {
+		pool.mu.Lock()
+		t.index = pool.submitted
+		pool.submitted++
+		pool.mu.Unlock()
+	}
+
+	err := pool.enqueue(ctx, poolCtx, poolDone, t, wait)
+	if err != nil {
+		releaseSlot()
+	}
+	if err != nil && ordered {
+		// the index has been assigned but the task will never be processed, so the sequencer must not wait for it
+		pool.ordered.skip(poolDone, pool.stopping, t.index)
+	}
+	return err
+}
+
+// enqueue puts a task in the input channel of the pool, or in its queue if the pool has priorities or fair scheduling
+func (pool *Pool[I, O]) enqueue(ctx context.Context, poolCtx context.Context, poolDone <-chan struct{}, t task[I, O], wait bool) error {
+	queue := pool.inCh
+	if pool.queue != nil {
+		queue = pool.queue.in
+	}
+	if !wait {
+		select {
+		case queue <- t:
+			return nil
+		default:
+			return ErrPoolFull
+		}
+	}
+	select {
+	case queue <- t:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-poolDone:
+		return poolCtx.Err()
+	case <-pool.stopping:
+		return ErrPoolStopped
+	}
+}
@@ -0,0 +1,139 @@
+package workerpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestTryProcess checks that TryProcess does not accept a value while the only worker of the pool is busy
+func TestTryProcess(t *testing.T) {
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		<-release
+		return in, nil
+	}
+	pool := workerpool.New(1, do)
+
+	if pool.TryProcess(0) {
+		t.Error("TryProcess should not accept values before the pool is started")
+	}
+	pool.Start(context.Background())
+
+	// the first value is sent with Process so that we are sure the worker is busy processing it
+	pool.Process(1)
+	if pool.TryProcess(2) {
+		t.Error("TryProcess should not accept values while the only worker is busy")
+	}
+	close(release)
+
+	go func() {
+		for range pool.OutCh {
+		}
+	}()
+	pool.Stop()
+	if pool.TryProcess(3) {
+		t.Error("TryProcess should not accept values after the pool is stopped")
+	}
+}
+
+// TestProcessContextErrors checks the errors returned by ProcessContext and ProcessTimeout in the different situations where the value
+// can not be accepted by the pool
+func TestProcessContextErrors(t *testing.T) {
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		<-release
+		return in, nil
+	}
+	pool := workerpool.New(1, do)
+
+	if err := pool.ProcessContext(context.Background(), 0); err != workerpool.ErrPoolNotStarted {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrPoolNotStarted, err)
+	}
+	pool.Start(context.Background())
+
+	// the worker is busy processing the first value, so the following values can not be accepted
+	if err := pool.ProcessContext(context.Background(), 1); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+	if err := pool.ProcessTimeout(2, time.Millisecond); err != workerpool.ErrPoolFull {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrPoolFull, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := pool.ProcessContext(ctx, 3); err != context.Canceled {
+		t.Errorf("Expected error %v - got %v", context.Canceled, err)
+	}
+	close(release)
+
+	go func() {
+		for range pool.OutCh {
+		}
+	}()
+	pool.Stop()
+	if err := pool.ProcessContext(context.Background(), 4); err != workerpool.ErrPoolStopped {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrPoolStopped, err)
+	}
+	// Process does not panic after the pool is stopped
+	pool.Process(5)
+}
+
+// TestProcessContextPoolCancelled checks that ProcessContext does not block forever if the context of the pool is cancelled,
+// i.e. when there are no more workers to take the value
+func TestProcessContextPoolCancelled(t *testing.T) {
+	do := func(in int) (int, error) {
+		return in, nil
+	}
+	pool := workerpool.New(1, do)
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+	cancel()
+
+	// since nobody reads OutCh the worker is blocked or has exited, so the values can not be accepted
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = pool.ProcessContext(context.Background(), i)
+	}
+	if err != context.Canceled {
+		t.Errorf("Expected error %v - got %v", context.Canceled, err)
+	}
+	pool.Stop()
+}
+
+// TestProcessTimeoutOrdered checks that an ordered pool keeps emitting its results in order when some values are not accepted
+func TestProcessTimeoutOrdered(t *testing.T) {
+	do := func(in int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return in, nil
+	}
+	pool := workerpool.New(2, do, workerpool.WithOrdered(2))
+	pool.Start(context.Background())
+
+	accepted := make(chan int, 100)
+	go func() {
+		defer pool.Stop()
+		defer close(accepted)
+		for i := 0; i < 100; i++ {
+			if pool.ProcessTimeout(i, 100*time.Microsecond) == nil {
+				accepted <- i
+			}
+		}
+	}()
+
+	results := []int{}
+	for res := range pool.OutCh {
+		results = append(results, res)
+	}
+	i := 0
+	for a := range accepted {
+		if i >= len(results) || results[i] != a {
+			t.Fatalf("Expected results to be the accepted values %v - got %v", a, results)
+		}
+		i++
+	}
+	if i != len(results) {
+		t.Errorf("Expected number of results %v - got %v", i, len(results))
+	}
+}
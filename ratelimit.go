@@ -0,0 +1,171 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit is the maximum rate at which a pool starts processing its inputs
+type RateLimit struct {
+	// Rate is the number of inputs started per second. It must be greater than 0.
+	Rate float64
+	// Burst is the number of inputs which can be started at once after a period of inactivity. Default is 1.
+	Burst int
+}
+
+// normalize checks the limit and sets the defaults
+func (limit RateLimit) normalize() RateLimit {
+	if limit.Rate <= 0 {
+		panic("the rate must be greater than 0")
+	}
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return limit
+}
+
+// WithRateLimit limits the rate at which the pool starts processing its inputs with a token bucket.
+// A worker which takes an input waits for a token before processing it, unless the context of the pool signals, in which case
+// the input is not processed. The limit can be changed with SetRateLimit.
+func WithRateLimit(limit RateLimit) Option {
+	limit = limit.normalize()
+	return func(o *options) {
+		o.rateLimit = &limit
+		o.rateKeyOf = nil
+	}
+}
+
+// WithKeyRateLimit limits the rate at which the pool starts processing the inputs with the same key, as returned by keyFn, with a token
+// bucket for each key, like WithRateLimit does for all the inputs.
+// The inputs whose key has no token wait holding a worker, so the limit is best combined with WithKeyLimit to avoid that the inputs of one key
+// take all the workers.
+func WithKeyRateLimit[I any, K comparable](keyFn func(input I) K, limit RateLimit) Option {
+	limit = limit.normalize()
+	keyOf := func(input I) any {
+		return keyFn(input)
+	}
+	return func(o *options) {
+		o.rateLimit = &limit
+		o.rateKeyOf = keyOf
+	}
+}
+
+// SetRateLimit changes the rate limit of the pool, which applies to each key if the pool is created with WithKeyRateLimit.
+// If the pool has no rate limit, the limit applies to all its inputs from now on.
+// The inputs already waiting for a token keep the wait computed with the previous limit.
+func (pool *Pool[I, O]) SetRateLimit(limit RateLimit) {
+	limit = limit.normalize()
+	pool.limiter.mu.Lock()
+	defer pool.limiter.mu.Unlock()
+	pool.limiter.limit = &limit
+}
+
+// RateLimit returns the rate limit of the pool and false if the pool has no rate limit
+func (pool *Pool[I, O]) RateLimit() (RateLimit, bool) {
+	pool.limiter.mu.Lock()
+	defer pool.limiter.mu.Unlock()
+	if pool.limiter.limit == nil {
+		return RateLimit{}, false
+	}
+	return *pool.limiter.limit, true
+}
+
+// tokenBucket holds the tokens available to start processing inputs. The tokens are negative when some inputs are waiting for them.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accrued since the last refill, up to the burst of limit
+func (b *tokenBucket) refill(now time.Time, limit RateLimit) {
+	b.tokens += now.Sub(b.last).Seconds() * limit.Rate
+	if b.tokens > float64(limit.Burst) {
+		b.tokens = float64(limit.Burst)
+	}
+	b.last = now
+}
+
+// reserve takes a token and returns how long to wait before the token is available
+func (b *tokenBucket) reserve(now time.Time, limit RateLimit) time.Duration {
+	b.refill(now, limit)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / limit.Rate * float64(time.Second))
+}
+
+// sweepEvery is the number of reservations after which the buckets of the keys which are full, and therefore equivalent to new buckets,
+// are dropped, so that the buckets of the keys not seen for a while do not accumulate
+const sweepEvery = 1024
+
+// rateLimiter limits the rate at which the workers of a pool start processing the inputs
+type rateLimiter[I any] struct {
+	mu sync.Mutex
+	// limit is nil if the pool has no rate limit
+	limit *RateLimit
+	// keyOf is nil if there is a single bucket for all the inputs
+	keyOf        func(I) any
+	bucket       *tokenBucket
+	buckets      map[any]*tokenBucket
+	reservations int
+}
+
+func newRateLimiter[I any](limit *RateLimit, keyOf func(I) any) *rateLimiter[I] {
+	return &rateLimiter[I]{limit: limit, keyOf: keyOf, buckets: map[any]*tokenBucket{}}
+}
+
+// bucketOf returns the bucket of input, creating it full if needed. It must be called holding the lock.
+func (l *rateLimiter[I]) bucketOf(input I, now time.Time) *tokenBucket {
+	if l.keyOf == nil {
+		if l.bucket == nil {
+			l.bucket = &tokenBucket{tokens: float64(l.limit.Burst), last: now}
+		}
+		return l.bucket
+	}
+	l.reservations++
+	if l.reservations%sweepEvery == 0 {
+		for key, b := range l.buckets {
+			b.refill(now, *l.limit)
+			if b.tokens >= float64(l.limit.Burst) {
+				delete(l.buckets, key)
+			}
+		}
+	}
+	key := l.keyOf(input)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// wait waits for a token to start processing input. Returns the error of ctx if ctx signals before the token is available,
+// in which case the token is given back.
+func (l *rateLimiter[I]) wait(ctx context.Context, input I) error {
+	l.mu.Lock()
+	if l.limit == nil {
+		l.mu.Unlock()
+		return nil
+	}
+	now := time.Now()
+	b := l.bucketOf(input, now)
+	d := b.reserve(now, *l.limit)
+	l.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		b.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
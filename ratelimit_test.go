@@ -0,0 +1,93 @@
+package workerpool_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+func identity(in int) (int, error) {
+	return in, nil
+}
+
+// processAll sends numOfInputs inputs to the pool, stops it and returns the time taken
+func processAll(pool *workerpool.Pool[int, int], numOfInputs int) time.Duration {
+	start := time.Now()
+	pool.Start(context.Background())
+	go func() {
+		for i := 0; i < numOfInputs; i++ {
+			pool.Process(i)
+		}
+		pool.Stop()
+	}()
+	for range pool.OutCh {
+	}
+	return time.Since(start)
+}
+
+// TestPoolRateLimit checks that, after the burst, the inputs are started at the rate set
+func TestPoolRateLimit(t *testing.T) {
+	pool := workerpool.New(10, identity, workerpool.WithRateLimit(workerpool.RateLimit{Rate: 100, Burst: 5}))
+	elapsed := processAll(pool, 25)
+	// 5 inputs are started at once and the other 20 at 100 per second
+	if elapsed < 180*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected the inputs to be processed in about %v - got %v", 200*time.Millisecond, elapsed)
+	}
+}
+
+// TestPoolKeyRateLimit checks that the rate limit applies to each key separately
+func TestPoolKeyRateLimit(t *testing.T) {
+	pool := workerpool.New(12, identity, workerpool.WithKeyRateLimit(func(in int) int { return in % 2 }, workerpool.RateLimit{Rate: 50}))
+	elapsed := processAll(pool, 12)
+	// each of the 2 keys has 6 inputs: the first is started at once and the other 5 at 50 per second
+	if elapsed < 80*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the inputs to be processed in about %v - got %v", 100*time.Millisecond, elapsed)
+	}
+}
+
+// TestPoolSetRateLimit checks that a rate limit can be set on a pool while it is running
+func TestPoolSetRateLimit(t *testing.T) {
+	pool := workerpool.New(10, identity)
+	if _, ok := pool.RateLimit(); ok {
+		t.Error("Expected the pool to have no rate limit")
+	}
+	limit := workerpool.RateLimit{Rate: 50, Burst: 1}
+	pool.SetRateLimit(limit)
+	if got, ok := pool.RateLimit(); !ok || got != limit {
+		t.Errorf("Expected rate limit %v - got %v", limit, got)
+	}
+	elapsed := processAll(pool, 6)
+	if elapsed < 80*time.Millisecond {
+		t.Errorf("Expected the inputs to be processed in about %v - got %v", 100*time.Millisecond, elapsed)
+	}
+}
+
+// TestPoolRateLimitAbort checks that the workers waiting for a token stop waiting when the pool is aborted
+func TestPoolRateLimitAbort(t *testing.T) {
+	pool := workerpool.New(3, identity,
+		workerpool.WithQueueSize(10),
+		workerpool.WithOutputBuffer(10),
+		workerpool.WithRateLimit(workerpool.RateLimit{Rate: 0.1}),
+	)
+	pool.Start(context.Background())
+	for i := 0; i < 5; i++ {
+		pool.Process(i)
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.Stats().Processed != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the first input to be processed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	unprocessed := pool.Abort()
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Abort not to wait for the tokens - it took %v", elapsed)
+	}
+	if len(unprocessed) != 4 {
+		t.Errorf("Expected %v inputs unprocessed - got %v", 4, unprocessed)
+	}
+}
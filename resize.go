@@ -0,0 +1,50 @@
+package workerpool
+
+// Resize changes the number of workers of the pool.
+// If the pool is running, new workers are spawned or running workers are retired. A worker which is retired completes the processing
+// of the input it holds, if any, before exiting, and Stop waits for it.
+// If the pool has not been started yet, n is the number of workers which are spawned by Start.
+// Returns ErrPoolStopped if the pool is stopped.
+func (pool *Pool[I, O]) Resize(n int) error {
+	if n < 1 {
+		panic("size must be greater than 0")
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if !pool.status.accepting() {
+		return ErrPoolStopped
+	}
+	if pool.status == Running || pool.status == Paused {
+		for len(pool.workers) < n {
+			pool.spawn()
+		}
+		for len(pool.workers) > n {
+			pool.retire()
+		}
+	}
+	pool.size = n
+	return nil
+}
+
+// Size returns the number of workers of the pool
+func (pool *Pool[I, O]) Size() int {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.size
+}
+
+// spawn launches a new worker. It must be called holding the lock of the pool.
+func (pool *Pool[I, O]) spawn() {
+	quit := make(chan struct{})
+	pool.workers = append(pool.workers, quit)
+	pool.doneWithInput.Add(1)
+	go pool.work(pool.ctx, pool.nextWorkerID, quit)
+	pool.nextWorkerID++
+}
+
+// retire signals the last worker spawned to exit. It must be called holding the lock of the pool.
+func (pool *Pool[I, O]) retire() {
+	last := len(pool.workers) - 1
+	close(pool.workers[last])
+	pool.workers = pool.workers[:last]
+}
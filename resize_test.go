@@ -0,0 +1,98 @@
+package workerpool_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolResize starts a pool with 1 worker, resizes it to 4 workers and checks that 4 values are then processed concurrently.
+// Then the pool is resized to 1 worker and the test checks that the values are processed one at a time.
+func TestPoolResize(t *testing.T) {
+	var mu sync.Mutex
+	running := 0
+	maxRunning := 0
+	release := make(chan struct{})
+	do := func(in int) (int, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return in, nil
+	}
+	// waitForRunning waits until n values are being processed
+	waitForRunning := func(n int) {
+		for {
+			mu.Lock()
+			r := running
+			mu.Unlock()
+			if r == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	pool := workerpool.New(1, do, workerpool.WithOutputBuffer(100))
+	pool.Start(context.Background())
+
+	if err := pool.Resize(4); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if pool.Size() != 4 {
+		t.Errorf("Expected size %v - got %v", 4, pool.Size())
+	}
+	for i := 0; i < 4; i++ {
+		if err := pool.ProcessTimeout(i, time.Second); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	// wait for all the 4 workers to be busy
+	waitForRunning(4)
+	if pool.TryProcess(4) {
+		t.Error("Expected the pool to be full")
+	}
+	mu.Lock()
+	if maxRunning != 4 {
+		t.Errorf("Expected number of values processed concurrently %v - got %v", 4, maxRunning)
+	}
+	mu.Unlock()
+
+	// shrink the pool: the workers retired complete the value they hold
+	if err := pool.Resize(1); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	close(release)
+	waitForRunning(0)
+	mu.Lock()
+	maxRunning = 0
+	mu.Unlock()
+	for i := 5; i < 10; i++ {
+		pool.Process(i)
+	}
+	pool.Stop()
+
+	results := 0
+	for range pool.OutCh {
+		results++
+	}
+	if results != 9 {
+		t.Errorf("Expected number of results %v - got %v", 9, results)
+	}
+	mu.Lock()
+	if maxRunning > 1 {
+		t.Errorf("Expected number of values processed concurrently after the pool has been shrunk %v - got %v", 1, maxRunning)
+	}
+	mu.Unlock()
+	if err := pool.Resize(2); err != workerpool.ErrPoolStopped {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrPoolStopped, err)
+	}
+}
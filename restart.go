@@ -0,0 +1,30 @@
+package workerpool
+
+import "context"
+
+// Reset brings a pool which has reached a terminal status back to the Created status, recreating its channels,
+// so that the same pool, with its size, its function and its options, can be started again to process another batch of values.
+// The channels OutCh, ErrCh and ResultCh are replaced by new ones, so they must be read again from the pool after Reset.
+// Returns ErrPoolNotTerminated if the pool has not reached a terminal status.
+func (pool *Pool[I, O]) Reset() error {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if !pool.status.Terminal() {
+		return ErrPoolNotTerminated
+	}
+	if err := pool.transition(Created); err != nil {
+		return err
+	}
+	pool.reset()
+	return nil
+}
+
+// Restart resets the pool and starts it again with ctx.
+// Returns ErrPoolNotTerminated if the pool has not reached a terminal status.
+func (pool *Pool[I, O]) Restart(ctx context.Context) error {
+	if err := pool.Reset(); err != nil {
+		return err
+	}
+	pool.Start(ctx)
+	return nil
+}
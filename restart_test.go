@@ -0,0 +1,101 @@
+package workerpool_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolRestart uses the same pool to process 3 batches of values, restarting it after each batch
+func TestPoolRestart(t *testing.T) {
+	do := func(in int) (int, error) {
+		return in * 2, nil
+	}
+	pool := workerpool.New(4, do, workerpool.WithResults())
+	if err := pool.Reset(); err != workerpool.ErrPoolNotTerminated {
+		t.Errorf("Expected error %v - got %v", workerpool.ErrPoolNotTerminated, err)
+	}
+	pool.Start(context.Background())
+
+	numOfBatches := 3
+	numOfInputSentToPool := 100
+	for b := 0; b < numOfBatches; b++ {
+		if b > 0 {
+			if err := pool.Restart(context.Background()); err != nil {
+				t.Fatalf("Unexpected error %v", err)
+			}
+		}
+		go func() {
+			defer pool.Stop()
+			for i := 0; i < numOfInputSentToPool; i++ {
+				pool.Process(i)
+			}
+		}()
+		sum := 0
+		maxIndex := 0
+		for res := range pool.ResultCh {
+			sum = sum + res.Output
+			if res.Index > maxIndex {
+				maxIndex = res.Index
+			}
+		}
+		expectedSum := numOfInputSentToPool * (numOfInputSentToPool - 1)
+		if expectedSum != sum {
+			t.Errorf("Batch %v: expected sum %v - got %v", b, expectedSum, sum)
+		}
+		// the indexes restart from 0 at each batch
+		if maxIndex != numOfInputSentToPool-1 {
+			t.Errorf("Batch %v: expected max index %v - got %v", b, numOfInputSentToPool-1, maxIndex)
+		}
+		if status := pool.Wait(); status != workerpool.Stopped {
+			t.Errorf("Batch %v: expected pool status %v - got %v", b, workerpool.Stopped, status)
+		}
+	}
+
+	pool.Restart(context.Background())
+	if err := pool.Restart(context.Background()); err != workerpool.ErrPoolNotTerminated {
+		t.Errorf("Expected error %v restarting a running pool - got %v", workerpool.ErrPoolNotTerminated, err)
+	}
+	pool.Stop()
+}
+
+// TestPoolRestartConcurrentStats restarts a pool while other goroutines read its Stats and wait for it to stop.
+// It is meant to be run with the race detector.
+func TestPoolRestartConcurrentStats(t *testing.T) {
+	pool := workerpool.New(2, func(in int) (int, error) { return in, nil }, workerpool.WithOutputBuffer(10))
+	pool.Start(context.Background())
+
+	var done atomic.Bool
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for !done.Load() {
+			pool.Stats()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for !done.Load() {
+			pool.Wait()
+		}
+	}()
+
+	for b := 0; b < 20; b++ {
+		for i := 0; i < 10; i++ {
+			pool.Process(i)
+		}
+		pool.Stop()
+		for range pool.OutCh {
+		}
+		if err := pool.Restart(context.Background()); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	done.Store(true)
+	pool.Stop()
+	wg.Wait()
+}
@@ -0,0 +1,47 @@
+package workerpool
+
+import (
+	"fmt"
+	"time"
+)
+
+// Result is the envelope emitted on ResultCh by a pool created with the WithResults option.
+// It pairs the input processed with the output or the error produced by its processing.
+type Result[I, O any] struct {
+	Input  I
+	Output O
+	// Err is the error returned by the processing of Input, nil if the processing succeeded
+	Err error
+	// Index is the position of Input in the sequence of values sent to the pool, starting from 0
+	Index int
+	// WorkerID identifies the worker which processed Input
+	WorkerID  int
+	StartedAt time.Time
+	Duration  time.Duration
+	// Attempts is the number of times the function of the pool has been called to process Input, more than 1 if it has been retried
+	Attempts int
+}
+
+// TaskError wraps the error returned by the processing of an input together with the input itself
+type TaskError[I any] struct {
+	Input I
+	Index int
+	Err   error
+}
+
+func (err TaskError[I]) Error() string {
+	return fmt.Sprintf("error processing input %v (index %v): %v", err.Input, err.Index, err.Err)
+}
+
+// Unwrap returns the original error, so that errors.Is and errors.As can inspect it
+func (err TaskError[I]) Unwrap() error {
+	return err.Err
+}
+
+// TaskError returns the error of the Result wrapped in a TaskError, or nil if the processing succeeded
+func (res Result[I, O]) TaskError() error {
+	if res.Err == nil {
+		return nil
+	}
+	return TaskError[I]{Input: res.Input, Index: res.Index, Err: res.Err}
+}
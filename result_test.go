@@ -0,0 +1,70 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolWithResults creates a pool with the WithResults option and checks that each Result received pairs the input with its output,
+// that each input has a distinct index and that the error received carries the input which generated it
+func TestPoolWithResults(t *testing.T) {
+	// this is the error value sent if an error occurs
+	conversionError := errors.New("Error occurred while processing")
+	// the error is generated when the input is this number
+	numberGeneratingError := 7
+
+	do := func(in int) (string, error) {
+		if in == numberGeneratingError {
+			return "", conversionError
+		}
+		return fmt.Sprintf("%v", in), nil
+	}
+	pool := workerpool.New(10, do, workerpool.WithResults())
+	pool.Start(context.Background())
+
+	numOfInputSentToPool := 1000
+	go func() {
+		defer pool.Stop()
+		for i := 0; i < numOfInputSentToPool; i++ {
+			pool.Process(i)
+		}
+	}()
+
+	indexes := map[int]bool{}
+	errorsReceived := []error{}
+	for res := range pool.ResultCh {
+		indexes[res.Index] = true
+		if res.Err != nil {
+			errorsReceived = append(errorsReceived, res.TaskError())
+			continue
+		}
+		if res.Output != fmt.Sprintf("%v", res.Input) {
+			t.Errorf("Expected output %v for input %v - got %v", fmt.Sprintf("%v", res.Input), res.Input, res.Output)
+		}
+		// since the inputs are sent by one goroutine in order, the index is the input value
+		if res.Index != res.Input {
+			t.Errorf("Expected index %v - got %v", res.Input, res.Index)
+		}
+	}
+
+	if len(indexes) != numOfInputSentToPool {
+		t.Errorf("Expected number of distinct indexes %v - got %v", numOfInputSentToPool, len(indexes))
+	}
+	if len(errorsReceived) != 1 {
+		t.Fatalf("Expected number of errors %v - got %v", 1, len(errorsReceived))
+	}
+	var taskErr workerpool.TaskError[int]
+	if !errors.As(errorsReceived[0], &taskErr) {
+		t.Fatalf("Expected a TaskError - got %T", errorsReceived[0])
+	}
+	if taskErr.Input != numberGeneratingError {
+		t.Errorf("Expected the input of the error to be %v - got %v", numberGeneratingError, taskErr.Input)
+	}
+	if !errors.Is(errorsReceived[0], conversionError) {
+		t.Errorf("Expected the error to wrap %v - got %v", conversionError, errorsReceived[0])
+	}
+}
@@ -0,0 +1,124 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy configures how the processing of an input is retried when the function of the pool returns an error
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times the function is called for the same input, including the first one
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt. The wait is multiplied by Multiplier at each following attempt,
+	// up to MaxBackoff. Defaults are 100 milliseconds, 2 and no maximum.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is the fraction of the wait which is randomized, between 0 and 1, so that the workers which fail at the same time
+	// do not retry at the same time. With Jitter 0.2 the wait varies between 80% and 120% of the backoff.
+	Jitter float64
+	// Retryable decides whether an error is worth another attempt. If it is nil, all the errors are retryable but panics and
+	// ErrCircuitOpen, so that an input fails fast when the circuit breaker of the pool is open.
+	Retryable func(error) bool
+	// OnAttempt, if not nil, is called after each failed attempt with the number of the attempt, starting from 1, its error and
+	// the wait before the next attempt, which is 0 if there is no next attempt
+	OnAttempt func(attempt int, err error, wait time.Duration)
+}
+
+// WithRetry makes the pool retry the processing of an input which fails, according to the policy
+func WithRetry(policy RetryPolicy) Option {
+	if policy.MaxAttempts < 1 {
+		panic("max attempts must be greater than 0")
+	}
+	return func(o *options) {
+		o.retry = &policy
+	}
+}
+
+// RetryError is the error returned when the processing of an input has failed after more than one attempt.
+// It holds the errors of all the attempts.
+type RetryError struct {
+	Errors []error
+}
+
+func (err RetryError) Error() string {
+	return fmt.Sprintf("failed after %v attempts: %v", len(err.Errors), err.Errors[len(err.Errors)-1])
+}
+
+// Unwrap returns the errors of all the attempts, so that errors.Is and errors.As can inspect any of them
+func (err RetryError) Unwrap() []error {
+	return err.Errors
+}
+
+// retry calls f until it succeeds, or fails with an error which is not retryable, or the maximum number of attempts is reached,
+// or ctx signals. Returns the output or the error of the last attempt and the number of attempts.
+// If more than one attempt has been made, the error is a RetryError.
+func retry[O any](ctx context.Context, policy RetryPolicy, f func() (O, error)) (O, error, int) {
+	errs := []error{}
+	for attempt := 1; ; attempt++ {
+		output, err := f()
+		if err == nil {
+			return output, nil, attempt
+		}
+		errs = append(errs, err)
+		var wait time.Duration
+		last := attempt >= policy.MaxAttempts || !policy.retryable(err) || ctx.Err() != nil
+		if !last {
+			wait = policy.backoff(attempt)
+		}
+		if policy.OnAttempt != nil {
+			policy.OnAttempt(attempt, err, wait)
+		}
+		if !last {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+				continue
+			case <-ctx.Done():
+				timer.Stop()
+			}
+		}
+		if len(errs) == 1 {
+			return output, err, attempt
+		}
+		return output, RetryError{Errors: errs}, attempt
+	}
+}
+
+// retryable returns true if err is worth another attempt
+func (policy RetryPolicy) retryable(err error) bool {
+	if policy.Retryable != nil {
+		return policy.Retryable(err)
+	}
+	_, panicked := err.(interface{ isPanic() })
+	return !panicked && !errors.Is(err, ErrCircuitOpen)
+}
+
+// backoff returns the wait after the attempt with the given number
+func (policy RetryPolicy) backoff(attempt int) time.Duration {
+	initial := policy.InitialBackoff
+	if initial <= 0 {
+		initial = 100 * time.Millisecond
+	}
+	multiplier := policy.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	wait := float64(initial)
+	for i := 1; i < attempt; i++ {
+		wait = wait * multiplier
+		if policy.MaxBackoff > 0 && wait > float64(policy.MaxBackoff) {
+			break
+		}
+	}
+	if policy.MaxBackoff > 0 && wait > float64(policy.MaxBackoff) {
+		wait = float64(policy.MaxBackoff)
+	}
+	if policy.Jitter > 0 {
+		wait = wait * (1 + policy.Jitter*(2*rand.Float64()-1))
+	}
+	return time.Duration(wait)
+}
@@ -0,0 +1,144 @@
+package workerpool_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+var errTransient = errors.New("transient error")
+var errPermanent = errors.New("permanent error")
+
+// TestPoolRetry checks that an input whose processing fails twice with a transient error is processed at the third attempt
+// and that the Result reports the number of attempts
+func TestPoolRetry(t *testing.T) {
+	var mu sync.Mutex
+	calls := map[int]int{}
+	do := func(in int) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[in]++
+		if calls[in] < 3 {
+			return 0, errTransient
+		}
+		return in, nil
+	}
+	failedAttempts := 0
+	policy := workerpool.RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+		Jitter:         0.5,
+		OnAttempt: func(attempt int, err error, wait time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			failedAttempts++
+		},
+	}
+	pool := workerpool.New(2, do, workerpool.WithResults(), workerpool.WithRetry(policy))
+	pool.Start(context.Background())
+	go func() {
+		defer pool.Stop()
+		pool.Process(1)
+	}()
+
+	for res := range pool.ResultCh {
+		if res.Err != nil {
+			t.Errorf("Unexpected error %v", res.Err)
+		}
+		if res.Attempts != 3 {
+			t.Errorf("Expected number of attempts %v - got %v", 3, res.Attempts)
+		}
+	}
+	if failedAttempts != 2 {
+		t.Errorf("Expected number of failed attempts %v - got %v", 2, failedAttempts)
+	}
+}
+
+// TestPoolRetryExhausted checks that, when all the attempts fail, the error returned holds the errors of all the attempts,
+// and that the errors which are not retryable are not retried
+func TestPoolRetryExhausted(t *testing.T) {
+	do := func(in int) (int, error) {
+		if in == 0 {
+			return 0, errPermanent
+		}
+		return 0, errTransient
+	}
+	policy := workerpool.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+		Retryable: func(err error) bool {
+			return err == errTransient
+		},
+	}
+	pool := workerpool.New(2, do, workerpool.WithResults(), workerpool.WithRetry(policy))
+	pool.Start(context.Background())
+	go func() {
+		defer pool.Stop()
+		pool.Process(0)
+		pool.Process(1)
+	}()
+
+	for res := range pool.ResultCh {
+		switch res.Input {
+		case 0:
+			if res.Err != errPermanent || res.Attempts != 1 {
+				t.Errorf("Expected error %v after 1 attempt - got %v after %v attempts", errPermanent, res.Err, res.Attempts)
+			}
+		case 1:
+			var retryErr workerpool.RetryError
+			if !errors.As(res.Err, &retryErr) {
+				t.Fatalf("Expected a RetryError - got %v", res.Err)
+			}
+			if len(retryErr.Errors) != 3 || res.Attempts != 3 {
+				t.Errorf("Expected %v attempts - got %v errors and %v attempts", 3, len(retryErr.Errors), res.Attempts)
+			}
+			if !errors.Is(res.Err, errTransient) {
+				t.Errorf("Expected error %v to wrap %v", res.Err, errTransient)
+			}
+		}
+	}
+}
+
+// TestPoolRetryErrorWrapsAllAttempts checks that the error returned when the attempts fail with different errors wraps all of them
+func TestPoolRetryErrorWrapsAllAttempts(t *testing.T) {
+	attempts := 0
+	do := func(in int) (int, error) {
+		attempts++
+		if attempts == 1 {
+			return 0, errTransient
+		}
+		return 0, errPermanent
+	}
+	pool := workerpool.New(1, do, workerpool.WithRetry(workerpool.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}))
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	_, err := pool.Submit(context.Background(), 1).Await(context.Background())
+	if !errors.Is(err, errTransient) || !errors.Is(err, errPermanent) {
+		t.Errorf("Expected error %v to wrap both %v and %v", err, errTransient, errPermanent)
+	}
+}
+
+// TestPoolRetryCancelled checks that the wait between two attempts is interrupted when the processing is cancelled
+func TestPoolRetryCancelled(t *testing.T) {
+	do := func(in int) (int, error) {
+		return 0, errTransient
+	}
+	policy := workerpool.RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour}
+	pool := workerpool.New(1, do, workerpool.WithRetry(policy))
+	pool.Start(context.Background())
+	defer pool.Stop()
+
+	f := pool.Submit(context.Background(), 1)
+	time.Sleep(10 * time.Millisecond)
+	f.Cancel()
+	select {
+	case <-f.Done():
+	case <-time.After(time.Second):
+		t.Fatal("The retry has not been interrupted")
+	}
+}
@@ -0,0 +1,74 @@
+package workerpool
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the activity of a pool
+type Stats struct {
+	// Status is the status of the pool
+	Status PoolStatus
+	// Size is the number of workers of the pool
+	Size int
+	// Busy is the number of workers which are processing an input
+	Busy int
+	// Queued is the number of inputs waiting for a worker to be available
+	Queued int
+	// Processed is the number of inputs whose processing has completed, successfully or not, and Failed the number of those which failed
+	Processed int64
+	Failed    int64
+	// ProcessingTime is the total time spent processing the inputs
+	ProcessingTime time.Duration
+	// Circuit is the state of the circuit breaker of the pool, CircuitClosed if the pool has no circuit breaker
+	Circuit CircuitState
+	// Priorities is the activity for each priority, indexed by priority, nil if the pool has no priorities
+	Priorities []PriorityStats
+	// Tenants is the activity for each tenant, nil if the pool has no fair scheduling
+	Tenants map[string]TenantStats
+	// KeyLimit reports the inputs held back because their key was saturated, the zero value if the pool has no key limit
+	KeyLimit KeyLimitStats
+	// ConcurrencyLimit is the limit of the inputs processed at the same time, which is Size if the pool has no adaptive concurrency limit
+	ConcurrencyLimit int
+}
+
+// counters are the counters updated by the workers to compute the Stats of the pool
+type counters struct {
+	busy           int64
+	processed      int64
+	failed         int64
+	processingTime int64
+}
+
+// Stats returns a snapshot of the activity of the pool
+func (pool *Pool[I, O]) Stats() Stats {
+	return Stats{
+		Status:           pool.GetStatus(),
+		Size:             pool.Size(),
+		Busy:             int(atomic.LoadInt64(&pool.counters.busy)),
+		Queued:           pool.QueueLen(),
+		Processed:        atomic.LoadInt64(&pool.counters.processed),
+		Failed:           atomic.LoadInt64(&pool.counters.failed),
+		ProcessingTime:   time.Duration(atomic.LoadInt64(&pool.counters.processingTime)),
+		Circuit:          pool.CircuitState(),
+		Priorities:       pool.PriorityStats(),
+		Tenants:          pool.TenantStats(),
+		KeyLimit:         pool.KeyLimitStats(),
+		ConcurrencyLimit: pool.ConcurrencyLimit(),
+	}
+}
+
+// started records that a worker has started processing an input
+func (c *counters) started() {
+	atomic.AddInt64(&c.busy, 1)
+}
+
+// completed records that a worker has completed the processing of an input
+func (c *counters) completed(d time.Duration, err error) {
+	atomic.AddInt64(&c.busy, -1)
+	atomic.AddInt64(&c.processed, 1)
+	atomic.AddInt64(&c.processingTime, int64(d))
+	if err != nil {
+		atomic.AddInt64(&c.failed, 1)
+	}
+}
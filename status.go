@@ -0,0 +1,136 @@
+package workerpool
+
+import (
+	"fmt"
+	"time"
+)
+
+type PoolStatus string
+
+// Created is the status of a pool which has not been started yet
+const Created = PoolStatus("Created")
+
+// Starting is the status of a pool while its workers are being launched
+const Starting = PoolStatus("Starting")
+
+// Running is the status of a pool whose workers are processing the values sent to it
+const Running = PoolStatus("Running")
+
+// Paused is the status of a pool whose workers do not take new values from the queue
+const Paused = PoolStatus("Paused")
+
+// Draining is the status of a pool which does not accept values anymore and is waiting for its workers to complete
+const Draining = PoolStatus("Draining")
+
+// Stopped is the status of a pool which has processed all the values accepted and whose workers have exited
+const Stopped = PoolStatus("Stopped")
+
+// Aborted is the status of a pool stopped with Abort, or with Drain whose context has signalled, before all the values accepted have been processed.
+// A pool whose Start context is cancelled reaches Stopped, not Aborted, when it is stopped.
+const Aborted = PoolStatus("Aborted")
+
+// Failed is the status of a pool which has been terminated because of a failure, e.g. a panic raised again by Stop
+const Failed = PoolStatus("Failed")
+
+// Started is the status of a pool which has been started.
+//
+// Deprecated: use Running
+const Started = Running
+
+// transitions lists, for each status, the statuses the pool can move to
+var transitions = map[PoolStatus][]PoolStatus{
+	Created:  {Starting, Draining},
+	Starting: {Running, Failed},
+	Running:  {Paused, Draining, Aborted},
+	Paused:   {Running, Draining, Aborted},
+	Draining: {Stopped, Aborted, Failed},
+	// a pool in a terminal status can only be reset
+	Stopped: {Created},
+	Aborted: {Created},
+	Failed:  {Created},
+}
+
+// Terminal returns true if the status is final, i.e. the pool has been stopped and can only be reset
+func (status PoolStatus) Terminal() bool {
+	return status == Stopped || status == Aborted || status == Failed
+}
+
+// accepting returns true if a pool in this status accepts values
+func (status PoolStatus) accepting() bool {
+	return status == Created || status == Starting || status == Running || status == Paused
+}
+
+// StatusChange describes a transition of a pool from one status to another
+type StatusChange struct {
+	From PoolStatus
+	To   PoolStatus
+	At   time.Time
+}
+
+// subscriptionBuffer is the number of status changes a subscriber can fall behind before the following ones are dropped
+const subscriptionBuffer = 16
+
+// transition moves the pool to a new status and notifies the subscribers.
+// Returns an error if the transition is not allowed. It must be called holding the lock of the pool.
+func (pool *Pool[I, O]) transition(to PoolStatus) error {
+	from := pool.status
+	allowed := false
+	for _, s := range transitions[from] {
+		if s == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("the pool can not move from status %v to status %v", from, to)
+	}
+	pool.status = to
+	change := StatusChange{From: from, To: to, At: time.Now()}
+	for _, sub := range pool.subscribers {
+		select {
+		case sub <- change:
+		default:
+		}
+	}
+	if to.Terminal() {
+		for _, sub := range pool.subscribers {
+			close(sub)
+		}
+		pool.subscribers = nil
+		close(pool.terminated)
+	}
+	return nil
+}
+
+// Subscribe returns a channel which receives the changes of status of the pool. The channel is closed when the pool reaches a terminal status.
+// If the subscriber does not keep up with the changes, the changes exceeding a buffer of 16 are dropped.
+func (pool *Pool[I, O]) Subscribe() <-chan StatusChange {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	sub := make(chan StatusChange, subscriptionBuffer)
+	if pool.status.Terminal() {
+		close(sub)
+		return sub
+	}
+	pool.subscribers = append(pool.subscribers, sub)
+	return sub
+}
+
+// Wait blocks until the pool reaches a terminal status, i.e. Stopped, Aborted or Failed, and returns that status
+func (pool *Pool[I, O]) Wait() PoolStatus {
+	// terminated is replaced when the pool is reset, so it is read holding the lock
+	pool.mu.Lock()
+	terminated := pool.terminated
+	pool.mu.Unlock()
+	<-terminated
+	return pool.GetStatus()
+}
+
+// GetStatus returns the status of the pool
+func (pool *Pool[I, O]) GetStatus() PoolStatus {
+	var st PoolStatus
+	pool.mu.Lock()
+	st = pool.status
+	pool.mu.Unlock()
+	return st
+}
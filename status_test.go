@@ -0,0 +1,74 @@
+package workerpool_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolSubscribe checks that a subscriber receives all the changes of status of a pool which is started and then stopped
+// and that the channel is closed when the pool reaches a terminal status
+func TestPoolSubscribe(t *testing.T) {
+	do := func(in int) (int, error) {
+		return in, nil
+	}
+	pool := workerpool.New(2, do)
+	if pool.GetStatus() != workerpool.Created {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Created, pool.GetStatus())
+	}
+	changes := pool.Subscribe()
+
+	pool.Start(context.Background())
+	if pool.GetStatus() != workerpool.Running {
+		t.Errorf("Expected pool status %v - got %v", workerpool.Running, pool.GetStatus())
+	}
+	pool.Stop()
+
+	got := []workerpool.PoolStatus{}
+	for c := range changes {
+		got = append(got, c.To)
+	}
+	expected := []workerpool.PoolStatus{workerpool.Starting, workerpool.Running, workerpool.Draining, workerpool.Stopped}
+	if !reflect.DeepEqual(expected, got) {
+		t.Errorf("Expected changes of status %v - got %v", expected, got)
+	}
+
+	// a subscription to a pool in a terminal status receives no changes
+	if _, more := <-pool.Subscribe(); more {
+		t.Error("Expected the channel of a subscription to a stopped pool to be closed")
+	}
+}
+
+// TestPoolWait checks that Wait blocks until the pool reaches a terminal status
+func TestPoolWait(t *testing.T) {
+	do := func(ctx context.Context, in int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+	pool := workerpool.NewWithContext(1, do)
+	pool.Start(context.Background())
+	pool.Process(1)
+
+	waitReturned := make(chan workerpool.PoolStatus)
+	go func() {
+		waitReturned <- pool.Wait()
+	}()
+	select {
+	case <-waitReturned:
+		t.Fatal("Wait returned before the pool has been stopped")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	pool.Abort()
+	select {
+	case status := <-waitReturned:
+		if status != workerpool.Aborted {
+			t.Errorf("Expected pool status %v - got %v", workerpool.Aborted, status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after the pool has been aborted")
+	}
+}
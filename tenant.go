@@ -0,0 +1,302 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TenantConfig configures how the inputs of a tenant are scheduled
+type TenantConfig struct {
+	// Weight is the share of the workers the tenant receives, relative to the other tenants, when several tenants have inputs waiting.
+	// Default is 1.
+	Weight int
+	// MaxConcurrency is the maximum number of inputs of the tenant processed at the same time. There is no limit if it is 0.
+	MaxConcurrency int
+}
+
+// FairConfig configures the fair scheduling of the inputs of a pool across tenants
+type FairConfig struct {
+	// Default is the configuration of the tenants which are not in Tenants, including the tenant of the inputs sent with Process
+	Default TenantConfig
+	// Tenants is the configuration of specific tenants
+	Tenants map[string]TenantConfig
+}
+
+// TenantStats is a snapshot of the activity of the pool for the inputs of a tenant
+type TenantStats struct {
+	// Queued is the number of inputs waiting in the queue and Running the number of those being processed
+	Queued  int
+	Running int
+	// Processed is the number of inputs whose processing has completed, successfully or not, and Failed the number of those which failed
+	Processed int64
+	Failed    int64
+	// WaitTime is the total time the inputs have waited in the queue before being taken by a worker
+	WaitTime time.Duration
+}
+
+// DefaultTenant is the tenant of the inputs sent without a tenant, e.g. with Process
+const DefaultTenant = ""
+
+// WithFairScheduling makes the pool share its workers across the tenants of the inputs with weighted deficit round robin:
+// each tenant with inputs waiting takes in turn a number of workers proportional to its weight, up to its maximum concurrency.
+// Each tenant has its own queue, whose size is set with WithQueueSize and is at least 1, so that a tenant which fills its queue does not
+// block the inputs of the other tenants.
+// It can not be used together with WithPriorities.
+func WithFairScheduling(config FairConfig) Option {
+	return func(o *options) {
+		o.fair = &config
+	}
+}
+
+// ProcessFor sends one value of tenant to the pool, like Process.
+// If the pool is not created with the WithFairScheduling option, the tenant is ignored.
+func (pool *Pool[I, O]) ProcessFor(tenant string, input I) {
+	t := pool.newTask(input)
+	t.tenant = tenant
+	pool.send(context.Background(), t, true, false)
+}
+
+// TenantStats returns a snapshot of the activity of the pool for each tenant which has sent inputs, nil if the pool has no fair scheduling
+func (pool *Pool[I, O]) TenantStats() map[string]TenantStats {
+	if pool.tenants == nil {
+		return nil
+	}
+	return pool.tenants.snapshot()
+}
+
+// tenants holds the state of the tenants of a pool which survives the reset of the pool: their configuration, their statistics and
+// the slots of their queues
+type tenants struct {
+	config   FairConfig
+	capacity int
+	mu       sync.Mutex
+	stats    map[string]*TenantStats
+	// slots holds, for each tenant, a token for each input of the tenant waiting in the queue
+	slots map[string]chan struct{}
+}
+
+func newTenants(config FairConfig, capacity int) *tenants {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &tenants{config: config, capacity: capacity, stats: map[string]*TenantStats{}, slots: map[string]chan struct{}{}}
+}
+
+func (ts *tenants) snapshot() map[string]TenantStats {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	snapshot := make(map[string]TenantStats, len(ts.stats))
+	for name, s := range ts.stats {
+		snapshot[name] = *s
+	}
+	return snapshot
+}
+
+// tenantConfig returns the configuration of tenant
+func (ts *tenants) tenantConfig(tenant string) TenantConfig {
+	config, ok := ts.config.Tenants[tenant]
+	if !ok {
+		config = ts.config.Default
+	}
+	if config.Weight < 1 {
+		config.Weight = 1
+	}
+	return config
+}
+
+// get returns the statistics and the slots of tenant, creating them if needed. It must be called holding the lock.
+func (ts *tenants) get(tenant string) (*TenantStats, chan struct{}) {
+	s, ok := ts.stats[tenant]
+	if !ok {
+		s = &TenantStats{}
+		ts.stats[tenant] = s
+		ts.slots[tenant] = make(chan struct{}, ts.capacity)
+	}
+	return s, ts.slots[tenant]
+}
+
+// acquire takes a slot in the queue of tenant, waiting until ctx signals, the pool context signals or the pool is stopped, if wait is true
+func (ts *tenants) acquire(ctx context.Context, poolCtx context.Context, stopping <-chan struct{}, tenant string, wait bool) error {
+	ts.mu.Lock()
+	_, slots := ts.get(tenant)
+	ts.mu.Unlock()
+	if !wait {
+		select {
+		case slots <- struct{}{}:
+			return nil
+		default:
+			return ErrPoolFull
+		}
+	}
+	// poolDone is nil, i.e. it never signals, until the pool is started
+	var poolDone <-chan struct{}
+	if poolCtx != nil {
+		poolDone = poolCtx.Done()
+	}
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-poolDone:
+		return poolCtx.Err()
+	case <-stopping:
+		return ErrPoolStopped
+	}
+}
+
+// release frees a slot in the queue of tenant. It must be called holding the lock.
+func (ts *tenants) release(tenant string) {
+	_, slots := ts.get(tenant)
+	<-slots
+}
+
+// eligible returns true if an input of tenant can be processed without exceeding its maximum concurrency. It must be called holding the lock.
+func (ts *tenants) eligible(tenant string) bool {
+	max := ts.tenantConfig(tenant).MaxConcurrency
+	s, _ := ts.get(tenant)
+	return max <= 0 || s.Running < max
+}
+
+// completed records that the processing of an input of tenant has completed
+func (ts *tenants) completed(tenant string, err error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	s, _ := ts.get(tenant)
+	s.Running--
+	s.Processed++
+	if err != nil {
+		s.Failed++
+	}
+}
+
+// givenBack records that an input of tenant taken by a worker has not been processed
+func (ts *tenants) givenBack(tenant string) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	s, _ := ts.get(tenant)
+	s.Running--
+}
+
+// tenantQueue holds the tasks of a tenant waiting in the queue
+type tenantQueue[I, O any] struct {
+	name  string
+	tasks []queuedTask[I, O]
+	// deficit is the number of tasks the tenant can still hand to the workers in its turn
+	deficit int
+}
+
+// tenantScheduler is the scheduler which hands the tasks of the tenants with weighted deficit round robin
+type tenantScheduler[I, O any] struct {
+	tenants *tenants
+	queues  map[string]*tenantQueue[I, O]
+	// ring holds the queues with tasks waiting, in round robin order, and pos is the position of the queue whose turn it is
+	ring  []*tenantQueue[I, O]
+	pos   int
+	count int
+}
+
+func newTenantScheduler[I, O any](tenants *tenants) *tenantScheduler[I, O] {
+	return &tenantScheduler[I, O]{tenants: tenants, queues: map[string]*tenantQueue[I, O]{}}
+}
+
+func (s *tenantScheduler[I, O]) len() int {
+	return s.count
+}
+
+func (s *tenantScheduler[I, O]) push(t task[I, O]) {
+	q, ok := s.queues[t.tenant]
+	if !ok {
+		q = &tenantQueue[I, O]{name: t.tenant}
+		s.queues[t.tenant] = q
+	}
+	if len(q.tasks) == 0 {
+		s.ring = append(s.ring, q)
+		if len(s.ring) == 1 {
+			s.pos = 0
+			s.grant(q)
+		}
+	}
+	q.tasks = append(q.tasks, queuedTask[I, O]{t: t, at: time.Now()})
+	s.count++
+	s.tenants.mu.Lock()
+	stats, _ := s.tenants.get(t.tenant)
+	stats.Queued++
+	s.tenants.mu.Unlock()
+}
+
+// grant gives its quantum to the queue whose turn it is
+func (s *tenantScheduler[I, O]) grant(q *tenantQueue[I, O]) {
+	s.tenants.mu.Lock()
+	defer s.tenants.mu.Unlock()
+	q.deficit = s.tenants.tenantConfig(q.name).Weight
+}
+
+// advance passes the turn to the next queue
+func (s *tenantScheduler[I, O]) advance() {
+	s.pos = (s.pos + 1) % len(s.ring)
+	s.grant(s.ring[s.pos])
+}
+
+// peek returns the first task of the queue whose turn it is. A tenant which has reached its maximum concurrency loses its turn.
+func (s *tenantScheduler[I, O]) peek() (task[I, O], bool) {
+	for i := 0; i < len(s.ring); i++ {
+		q := s.ring[s.pos]
+		s.tenants.mu.Lock()
+		eligible := s.tenants.eligible(q.name)
+		s.tenants.mu.Unlock()
+		if eligible {
+			return q.tasks[0].t, true
+		}
+		s.advance()
+	}
+	return task[I, O]{}, false
+}
+
+// pop removes the task returned by peek
+func (s *tenantScheduler[I, O]) pop() {
+	q := s.ring[s.pos]
+	item := q.tasks[0]
+	q.tasks = q.tasks[1:]
+	q.deficit--
+	s.count--
+	s.tenants.mu.Lock()
+	stats, _ := s.tenants.get(q.name)
+	stats.Queued--
+	stats.Running++
+	stats.WaitTime += time.Since(item.at)
+	s.tenants.release(q.name)
+	s.tenants.mu.Unlock()
+	switch {
+	case len(q.tasks) == 0:
+		// the queue leaves the ring and the turn passes to the next queue
+		q.tasks = nil
+		s.ring = append(s.ring[:s.pos], s.ring[s.pos+1:]...)
+		if len(s.ring) > 0 {
+			s.pos %= len(s.ring)
+			s.grant(s.ring[s.pos])
+		}
+	case q.deficit <= 0:
+		s.advance()
+	}
+}
+
+func (s *tenantScheduler[I, O]) drain() []task[I, O] {
+	tasks := []task[I, O]{}
+	s.tenants.mu.Lock()
+	for _, q := range s.ring {
+		stats, _ := s.tenants.get(q.name)
+		for _, item := range q.tasks {
+			tasks = append(tasks, item.t)
+			stats.Queued--
+			s.tenants.release(q.name)
+		}
+		q.tasks = nil
+	}
+	s.tenants.mu.Unlock()
+	s.ring = nil
+	s.pos = 0
+	s.count = 0
+	return tasks
+}
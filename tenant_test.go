@@ -0,0 +1,145 @@
+package workerpool_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EnricoPicci/workerpool"
+)
+
+// TestPoolFairScheduling checks that the workers are shared across the tenants in proportion to their weights
+func TestPoolFairScheduling(t *testing.T) {
+	pool, release := blockedPool(t,
+		workerpool.WithQueueSize(10),
+		workerpool.WithOutputBuffer(20),
+		workerpool.WithFairScheduling(workerpool.FairConfig{
+			Tenants: map[string]workerpool.TenantConfig{"big": {Weight: 2}},
+		}),
+	)
+	for i := 0; i < 6; i++ {
+		pool.ProcessFor("big", i)
+	}
+	for i := 100; i < 103; i++ {
+		pool.ProcessFor("small", i)
+	}
+	deadline := time.Now().Add(time.Second)
+	for pool.QueueLen() != 9 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %v inputs in the queue - got %v", 9, pool.QueueLen())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	pool.Stop()
+
+	outputs := []int{}
+	for out := range pool.OutCh {
+		outputs = append(outputs, out)
+	}
+	expected := []int{-1, 0, 1, 100, 2, 3, 101, 4, 5, 102}
+	if len(outputs) != len(expected) {
+		t.Fatalf("Expected outputs %v - got %v", expected, outputs)
+	}
+	for i := range expected {
+		if outputs[i] != expected[i] {
+			t.Fatalf("Expected outputs %v - got %v", expected, outputs)
+		}
+	}
+
+	stats := pool.Stats().Tenants
+	expectedProcessed := map[string]int64{workerpool.DefaultTenant: 1, "big": 6, "small": 3}
+	for tenant, processed := range expectedProcessed {
+		s := stats[tenant]
+		if s.Processed != processed || s.Queued != 0 || s.Running != 0 {
+			t.Errorf("Unexpected stats for tenant %q: %+v", tenant, s)
+		}
+	}
+}
+
+// TestPoolTenantMaxConcurrency checks that the inputs of a tenant are not processed by more workers than its maximum concurrency,
+// while the other tenants use the remaining workers
+func TestPoolTenantMaxConcurrency(t *testing.T) {
+	var mu sync.Mutex
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+	do := func(in string) (string, error) {
+		mu.Lock()
+		running[in]++
+		if running[in] > maxRunning[in] {
+			maxRunning[in] = running[in]
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running[in]--
+		mu.Unlock()
+		return in, nil
+	}
+	pool := workerpool.New(4, do,
+		workerpool.WithQueueSize(20),
+		workerpool.WithOutputBuffer(40),
+		workerpool.WithFairScheduling(workerpool.FairConfig{
+			Tenants: map[string]workerpool.TenantConfig{"limited": {MaxConcurrency: 1}},
+		}),
+	)
+	pool.Start(context.Background())
+	for i := 0; i < 10; i++ {
+		pool.ProcessFor("limited", "limited")
+		pool.ProcessFor("free", "free")
+	}
+	pool.Stop()
+	for range pool.OutCh {
+	}
+
+	if maxRunning["limited"] != 1 {
+		t.Errorf("Expected at most %v inputs of the limited tenant processed at the same time - got %v", 1, maxRunning["limited"])
+	}
+	if maxRunning["free"] < 2 {
+		t.Errorf("Expected the other tenant to use more workers - got %v", maxRunning["free"])
+	}
+	if processed := pool.TenantStats()["limited"].Processed; processed != 10 {
+		t.Errorf("Expected %v inputs of the limited tenant processed - got %v", 10, processed)
+	}
+}
+
+// TestPoolTenantQueues checks that a tenant which fills its queue does not block the inputs of the other tenants
+func TestPoolTenantQueues(t *testing.T) {
+	pool, release := blockedPool(t,
+		workerpool.WithQueueSize(1),
+		workerpool.WithOutputBuffer(10),
+		workerpool.WithFairScheduling(workerpool.FairConfig{}),
+	)
+	pool.ProcessFor("a", 1)
+	sent := make(chan struct{})
+	go func() {
+		pool.ProcessFor("a", 2)
+		close(sent)
+	}()
+	done := make(chan struct{})
+	go func() {
+		pool.ProcessFor("b", 3)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("The input of a tenant has been blocked by the full queue of another tenant")
+	}
+	select {
+	case <-sent:
+		t.Error("Expected the second input of the tenant to wait for room in its queue")
+	default:
+	}
+	close(release)
+	<-sent
+	pool.Stop()
+	outputs := 0
+	for range pool.OutCh {
+		outputs++
+	}
+	if outputs != 4 {
+		t.Errorf("Expected %v outputs - got %v", 4, outputs)
+	}
+}
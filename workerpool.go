@@ -6,7 +6,10 @@ Package workerpool implements a worker pool, i.e. a pool of goroutines which per
 Create the pool using the New function. The New function expects the size of the pool, i.e. the number of goroutines processing the input concurrently,
 and a function which expects an input of type I and returns an output of type O or an error.
 
-Once the pool has been created it can be started with the method Start().
+If the processing has to be cancellable, create the pool using the NewWithContext function. NewWithContext expects a function which receives
+also a context.Context. The context is derived from the one passed to Start and is cancelled when that context is cancelled or when the pool is aborted.
+
+Once the pool has been created it can be started with the method Start(ctx).
 
 A client can send a value (of type I) to the pool to be processed using the method Process(input I).
 
@@ -17,6 +20,110 @@ There are different ways to process the results that the pool has produced.
 # Process the results reading from the pool channels
 A client can read the results produced by the pool from the channel OutCh and the errors from the channel ErrCh.
 
+# Process the results as envelopes
+If the pool is created with the WithResults option, the pool emits on the channel ResultCh a Result for each input processed.
+A Result pairs the input with the output or the error produced by its processing, together with some metadata about the processing.
+
+# Options
+New and NewWithContext accept a list of Option values which configure the pool. For instance WithQueueSize sets the number of values
+which can wait in a queue for a worker to be available, while WithOutputBuffer and WithErrorBuffer set the size of the buffers of
+the channels OutCh and ErrCh. QueueLen returns the number of values waiting in the queue.
+
+# Status
+GetStatus returns the status of the pool. A pool is Created by its constructors and moves to Starting and then Running when it is started.
+A running pool can be Paused. When it is stopped, the pool is Draining until its workers have exited and then it reaches one of the terminal
+statuses: Stopped, Aborted if it has been stopped with Abort or its Drain deadline has expired, or Failed. A pool whose Start
+context is cancelled reaches Stopped when it is stopped. Wait blocks until the pool reaches a terminal status and
+Subscribe returns a channel which receives the changes of status.
+
+# Pause and Resume
+Pause stops the workers from taking new values from the queue, while the values being processed complete. Resume lets the workers take
+values from the queue again. Stopping a paused pool resumes it, so that the values in the queue are processed.
+
+# Reuse a pool
+A pool which has reached a terminal status can be reset with Reset, which recreates its channels, and then started again, so that the same
+pool can process many batches of values. Restart(ctx) resets and starts the pool in one call.
+
+# Drain and Abort
+Stop waits for all the values accepted by the pool to be processed. Drain does the same but gives up when its context signals,
+cancelling the processing of the remaining values. Abort cancels the processing of the values being processed and returns the values
+which were waiting in the queue.
+
+# Resize the pool
+The number of workers of a started pool can be changed with the method Resize(n). Workers which are retired complete the processing of
+the input they hold before exiting. Size returns the current number of workers.
+
+# Autoscaling
+Stats returns a snapshot of the activity of the pool. An Autoscaler samples periodically the Stats of a pool and resizes it, between a minimum
+and a maximum number of workers, depending on the backlog of the queue, on the utilisation of the workers and on the latency of the processing.
+
+# Send inputs without blocking
+Process blocks until a worker is available to take the value. TryProcess returns immediately reporting whether the value has been accepted,
+ProcessContext waits until the context signals and ProcessTimeout waits at most for a certain duration. These methods return typed errors,
+ErrPoolFull, ErrPoolStopped and ErrPoolNotStarted, when the value can not be accepted.
+
+# Submit an input and await its result
+A client can also send a value to the pool using the method Submit(ctx, input), which returns a Future. The output or the error produced by the
+processing of the input is delivered only to the Future, and not to the pool channels, so that Submit can be used together with Process.
+Future.Await waits for the result, Future.Done signals when the result is available and Future.Cancel cancels the processing.
+
+# Preserve the order of the inputs
+Workers emit results in the order they complete their processing. If the pool is created with the WithOrdered option, the results are instead
+emitted in the order the inputs have been sent to the pool. The window passed to WithOrdered limits the number of inputs which can be waiting for
+their result to be emitted: when the window is full, Process blocks until the result of the oldest input is emitted.
+
+# Panics
+If the processing of an input panics, the worker recovers the panic and emits a PanicError, carrying the recovered value, the stack trace and
+the input, as any other error. The worker then continues processing the following inputs.
+If the pool is created with the WithRepanic option, the panic is not emitted and is instead raised again by Stop on the goroutine of its caller.
+
+# Retry
+If the pool is created with the WithRetry option, the function of the pool is called again when it fails with a retryable error,
+waiting between the attempts for an exponential backoff with jitter, as configured by the RetryPolicy.
+
+# Circuit breaker
+If the pool is created with the WithCircuitBreaker option, the function of the pool is wrapped by a circuit breaker. When the rate of
+failures over the last calls reaches a threshold, the circuit opens and the processing of the inputs fails immediately with ErrCircuitOpen,
+or the pool is paused, until a probe call succeeds. CircuitState returns the state of the circuit.
+
+# Dead letters
+If the pool is created with the WithDeadLetter option, the inputs whose processing fails are put, with their error, the number of attempts
+and the timestamps of the processing, in a DeadLetter, after all the attempts allowed by the retry policy. MemoryDeadLetter keeps them in memory,
+FileDeadLetter appends them to a file as JSON lines. ProcessDeadLetterFile sends the inputs of such a file to a pool, DeadLetterInputs reads them
+to pass them to MapReduce.
+
+# Priorities
+If the pool is created with the WithPriorities option, the inputs waiting in the queue are taken by the workers in order of priority.
+ProcessWithPriority sends an input with a priority, the other methods send it with the default priority. With aging, the priority of
+an input grows while it waits, so that the inputs with low priority are not starved. PriorityStats returns the activity for each priority.
+
+# Fair scheduling across tenants
+If the pool is created with the WithFairScheduling option, the inputs sent with ProcessFor are queued per tenant and the workers are shared
+across the tenants with weighted deficit round robin, so that a tenant with many inputs does not monopolise the workers.
+Each tenant can be given a weight and a maximum concurrency. TenantStats returns the activity for each tenant.
+
+# Keyed pools
+A pool created with NewKeyed, or NewKeyedWithContext, processes the inputs with the same key one at a time, in the order they have been sent,
+while the inputs with different keys are processed in parallel.
+With the WithKeyLimit option, at most a given number of inputs with the same key are processed at the same time. The inputs of a saturated key
+are held back without holding back the inputs of the other keys: they do not count towards the size of the queue and WithKeyBacklog bounds
+the inputs of each key waiting in the queue. KeyLimitStats reports how many inputs have been held back and for how long.
+
+# Rate limiting
+If the pool is created with the WithRateLimit option, the workers start processing the inputs at most at the rate set, with bursts, as
+with a token bucket. With WithKeyRateLimit the limit applies to the inputs of each key. The limit can be changed with SetRateLimit.
+A worker waiting for a token stops waiting when the context of the pool signals.
+
+# Adaptive concurrency
+If the pool is created with the WithAdaptiveConcurrency option, the number of inputs processed at the same time is kept within a limit,
+lower than or equal to the number of workers, which grows while the latency is stable and shrinks when the latency rises or errors occur.
+ConcurrencyLimit returns the current limit.
+
+# Deduplication
+If the pool is created with the WithDedup option, the inputs sent with Submit which have the same key as an input waiting or being processed
+are not processed again: the result of the input processed is sent to the Future of each submitter. With WithDedupWindow, the result of
+an input processed successfully is returned also to the inputs with the same key submitted within the window.
+
 # Reduce the results into an accumulator
 A client can reduce the results sent by the pool into an accumulator using the function Reduce.
 
@@ -29,101 +136,594 @@ package workerpool
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
+	"time"
 )
 
 // Pool implements a worker pool
 type Pool[I, O any] struct {
-	inCh          chan I
-	OutCh         chan O
-	ErrCh         chan error
+	inCh  chan task[I, O]
+	OutCh chan O
+	ErrCh chan error
+	// ResultCh is used, instead of OutCh and ErrCh, if the pool is created with the WithResults option, otherwise it is nil
+	ResultCh chan Result[I, O]
+	// doneWithInput is incremented for each worker spawned and decremented when the worker exits
 	doneWithInput *sync.WaitGroup
-	size          int
-	do            func(I) (O, error)
-	mu            *sync.Mutex
-	status        PoolStatus
-}
-type PoolStatus string
-
-const new = PoolStatus("New")
-const Started = PoolStatus("Started")
-const Stopped = PoolStatus("Stopped")
-
-// New creates a Pool and returns a pointer to it
-func New[I, O any](size int, do func(input I) (O, error)) *Pool[I, O] {
-	inCh := make(chan I)
-	outCh := make(chan O)
-	errCh := make(chan error)
+	// size is the number of workers the pool runs and workers holds the channels used to retire the workers running
+	size         int
+	workers      []chan struct{}
+	nextWorkerID int
+	do           func(context.Context, I) (O, error)
+	mu           *sync.Mutex
+	status       PoolStatus
+	// subscribers receive the changes of status and terminated is closed when the pool reaches a terminal status
+	subscribers []chan StatusChange
+	terminated  chan struct{}
+	// paused is not nil while the pool is paused and is closed when the pool is resumed
+	paused chan struct{}
+	// pausedByCircuit is true if the pool has been paused by its circuit breaker, rather than by Pause
+	pausedByCircuit bool
+	// ctx is the context shared by the workers, derived from the one passed to Start, and cancel aborts it
+	ctx    context.Context
+	cancel context.CancelFunc
+	// submitted counts the input values sent to the pool and is used to assign the index to each of them
+	submitted int
+	// senders counts the goroutines which are sending a value to the workers, so that Stop can wait for them before closing inCh.
+	// stopping is closed by Stop to unblock them and sendersDone is signalled when they are all done
+	senders     int
+	stopping    chan struct{}
+	sendersDone *sync.Cond
+	// ordered reorders the results if the pool is created with the WithOrdered option, otherwise it is nil
+	ordered *sequencer[I, O]
+	// repanic is true if the pool is created with the WithRepanic option and panicked holds the first panic recovered in that case
+	repanic  bool
+	panicked *PanicError[I]
+	// breaker is the circuit breaker wrapping the do function, nil if the pool is not created with the WithCircuitBreaker option
+	breaker *breaker
+	// deadLetter receives the inputs whose processing fails, nil if the pool is not created with the WithDeadLetter option
+	deadLetter DeadLetter[I]
+	// queue holds the inputs waiting for a worker and hands them to the workers in the order decided by its scheduler, if the pool
+	// is created with the WithPriorities or the WithFairScheduling option, otherwise it is nil and the inputs wait in inCh
+	queue *dispatcher[I, O]
+	// priorityCounters counts the activity for each priority, if the pool is created with the WithPriorities option
+	priorityCounters *priorityCounters
+	// tenants holds the state of the tenants, if the pool is created with the WithFairScheduling option
+	tenants *tenants
+	// keyOf returns the key of an input, if the pool is created with NewKeyed or WithKeyLimit, keyCounters counts the inputs held back
+	// and keySlots, nil if the pool is not created with WithKeyBacklog, bounds the inputs of each key waiting in the queue
+	keyOf       func(I) any
+	keyCounters *keyCounters
+	keySlots    *keySlots
+	// limiter limits the rate at which the workers start processing the inputs, if the pool has a rate limit
+	limiter *rateLimiter[I]
+	// concurrency limits the number of inputs processed at the same time, nil if the pool is not created with WithAdaptiveConcurrency
+	concurrency *concurrencyLimiter
+	// dedup coalesces the inputs with the same key sent with Submit, nil if the pool is not created with WithDedup
+	dedup *dedup[I, O]
+	// options is the configuration of the pool, kept to recreate the channels when the pool is reset
+	options options
+	// leftover holds the tasks taken by the workers after the context of the pool has been cancelled, which have not been processed
+	leftover []task[I, O]
+	// counters are used to compute the Stats of the pool. They are allocated separately to guarantee the alignment required by sync/atomic
+	counters *counters
+}
+
+// task is the unit of work sent to the workers
+type task[I, O any] struct {
+	input I
+	// index is the position of the input among the values sent with Process, -1 for the values sent with Submit
+	index int
+	// future and ctx are set for the values sent with Submit: future receives the result and ctx is the context passed to Submit
+	future *Future[O]
+	ctx    context.Context
+	// priority is the priority of the input if the pool is created with the WithPriorities option
+	priority int
+	// tenant is the tenant of the input if the pool is created with the WithFairScheduling option
+	tenant string
+	// key is the key of the input if the pool is created with NewKeyed or WithKeyLimit
+	key any
+}
+
+// New creates a Pool and returns a pointer to it. The pool can be configured passing a list of Option values.
+func New[I, O any](size int, do func(input I) (O, error), opts ...Option) *Pool[I, O] {
+	doWithContext := func(_ context.Context, input I) (O, error) {
+		return do(input)
+	}
+	return NewWithContext(size, doWithContext, opts...)
+}
+
+// NewWithContext creates a Pool whose workers call a function which receives a context together with the input value.
+// The context passed to each invocation is cancelled when the context passed to Start is cancelled or when the pool is aborted,
+// so that a long running processing can be interrupted.
+func NewWithContext[I, O any](size int, do func(ctx context.Context, input I) (O, error), opts ...Option) *Pool[I, O] {
+	o := newOptions(opts)
+	if size < 1 {
+		panic("size must be greater than 0")
+	}
 	var doneWithInput sync.WaitGroup
-	doneWithInput.Add(size)
 	var mu sync.Mutex
-	pool := Pool[I, O]{inCh, outCh, errCh, &doneWithInput, size, do, &mu, new}
+	pool := Pool[I, O]{doneWithInput: &doneWithInput, size: size, do: do, mu: &mu, options: o}
+	pool.sendersDone = sync.NewCond(&mu)
+	pool.counters = &counters{}
+	pool.repanic = o.repanic
+	if o.breaker != nil {
+		pool.breaker = newBreaker(*o.breaker)
+		if o.breaker.PauseOnOpen {
+			pool.breaker.onChange = pool.onCircuitChange
+		}
+	}
+	if o.deadLetter != nil {
+		deadLetter, ok := o.deadLetter.(DeadLetter[I])
+		if !ok {
+			panic(fmt.Sprintf("the dead letter of the pool must be a DeadLetter[%T]", *new(I)))
+		}
+		pool.deadLetter = deadLetter
+	}
+	schedulers := 0
+	for _, set := range []bool{o.priorities != nil, o.fair != nil, o.keyOf != nil} {
+		if set {
+			schedulers++
+		}
+	}
+	if schedulers > 1 {
+		panic("priorities, fair scheduling and keys can not be combined")
+	}
+	var rateKeyOf func(I) any
+	if o.rateKeyOf != nil {
+		rateKeyOf = keyOfFunc[I](o.rateKeyOf)
+	}
+	pool.limiter = newRateLimiter(o.rateLimit, rateKeyOf)
+	if o.adaptive != nil {
+		pool.concurrency = newConcurrencyLimiter(*o.adaptive)
+	}
+	if o.keyBacklog > 0 && o.keyOf == nil {
+		panic("the key backlog requires NewKeyed or the WithKeyLimit option")
+	}
+	if o.dedupWindow > 0 && o.dedupKeyOf == nil {
+		panic("the dedup window requires the WithDedup option")
+	}
+	if o.dedupKeyOf != nil {
+		pool.dedup = newDedup[I, O](keyOfFunc[I](o.dedupKeyOf), o.dedupWindow)
+	}
+	if o.keyOf != nil {
+		pool.keyOf = keyOfFunc[I](o.keyOf)
+		pool.keyCounters = &keyCounters{}
+		if o.keyBacklog > 0 {
+			pool.keySlots = newKeySlots(o.keyBacklog)
+		}
+	}
+	if o.priorities != nil {
+		pool.priorityCounters = &priorityCounters{levels: make([]PriorityStats, o.priorities.Levels)}
+	}
+	if o.fair != nil {
+		pool.tenants = newTenants(*o.fair, o.queueSize)
+	}
+	pool.reset()
 	return &pool
 }
 
+// reset creates the channels of the pool and brings it to the Created status. It must be called holding the lock of the pool,
+// or before the pool is shared.
+func (pool *Pool[I, O]) reset() {
+	o := pool.options
+	pool.inCh = make(chan task[I, O], o.queueSize)
+	pool.queue = nil
+	switch {
+	case o.priorities != nil:
+		// the inputs wait in the queue, which hands them to the workers when they are available
+		pool.inCh = make(chan task[I, O])
+		pool.queue = newDispatcher[I, O](newPriorityScheduler[I, O](*o.priorities, pool.priorityCounters), maxInt(o.queueSize, 1))
+	case o.fair != nil:
+		// each tenant has its own slots in the queue
+		pool.inCh = make(chan task[I, O])
+		pool.queue = newDispatcher[I, O](newTenantScheduler[I, O](pool.tenants), -1)
+	case pool.keyOf != nil:
+		// the inputs wait in the queue while their key is saturated, without counting towards the size of the queue
+		pool.inCh = make(chan task[I, O])
+		pool.queue = newDispatcher[I, O](newKeyScheduler[I, O](o.keyLimit, pool.keyCounters, pool.keySlots), maxInt(o.queueSize, 1))
+	}
+	pool.OutCh = make(chan O, o.outputBuffer)
+	pool.ErrCh = make(chan error, o.errorBuffer)
+	pool.ResultCh = nil
+	if o.results {
+		pool.ResultCh = make(chan Result[I, O], o.outputBuffer)
+	}
+	pool.ordered = nil
+	if o.ordered {
+		pool.ordered = newSequencer[I, O](o.window)
+	}
+	pool.stopping = make(chan struct{})
+	pool.terminated = make(chan struct{})
+	pool.status = Created
+	pool.ctx = nil
+	pool.cancel = nil
+	pool.submitted = 0
+	pool.workers = nil
+	pool.panicked = nil
+	pool.leftover = nil
+}
+
 // Start starts the pool
+// Start has no effect if the pool is not in the Created status. A pool which has been stopped can be started again with Restart.
 func (pool *Pool[I, O]) Start(ctx context.Context) {
 	pool.mu.Lock()
-	if pool.status == Started {
+	defer pool.mu.Unlock()
+	if pool.status != Created {
 		return
 	}
-	pool.status = Started
-	pool.mu.Unlock()
+	pool.transition(Starting)
+	pool.ctx, pool.cancel = context.WithCancel(ctx)
+	if pool.ordered != nil {
+		go pool.ordered.run(pool.ctx, pool.emit)
+	}
+	if pool.queue != nil {
+		go pool.queue.run(pool.ctx, pool.inCh, pool.putBack)
+	}
 	for i := 0; i < pool.size; i++ {
-		go func() { // these workers complete when pool.inCh is closed
-			defer pool.doneWithInput.Done()
-			for {
-				select {
-				case input, more := <-pool.inCh:
-					if !more {
-						return
-					}
-					output, e := pool.do(input)
-					if e != nil {
-						if ctx.Err() != nil {
-							// it the context has signalled a termination signal, exit the worker
-							return
-						}
-						pool.ErrCh <- e
-						continue
-					}
-					pool.OutCh <- output
-				case <-ctx.Done():
+		pool.spawn()
+	}
+	pool.transition(Running)
+}
+
+// work is the loop run by each worker. A worker completes when pool.inCh is closed, when the context signals
+// or when the worker is retired closing its quit channel.
+func (pool *Pool[I, O]) work(ctx context.Context, workerID int, quit <-chan struct{}) {
+	defer pool.doneWithInput.Done()
+	for {
+		// a worker which has been retired, or whose context has been cancelled, must not take other inputs, even if they are available
+		select {
+		case <-quit:
+			return
+		case <-ctx.Done():
+			return
+		default:
+		}
+		if !pool.waitIfPaused(ctx, quit) {
+			continue
+		}
+		select {
+		case <-quit:
+			return
+		case t, more := <-pool.inCh:
+			if !more {
+				return
+			}
+			// the pool may have been paused while the worker was waiting for a value, in which case the value must wait for the pool
+			// to be resumed. A retired worker processes the value it has taken anyway.
+			if !pool.waitIfPaused(ctx, nil) || ctx.Err() != nil || pool.limiter.wait(ctx, t.input) != nil {
+				pool.giveBack(t)
+				return
+			}
+			generation := 0
+			if pool.concurrency != nil {
+				var err error
+				if generation, err = pool.concurrency.acquire(ctx); err != nil {
+					pool.giveBack(t)
 					return
 				}
 			}
+			res := Result[I, O]{Input: t.input, Index: t.index, WorkerID: workerID, StartedAt: time.Now()}
+			pool.counters.started()
+			res.Output, res.Err, res.Attempts = pool.invoke(ctx, t)
+			res.Duration = time.Since(res.StartedAt)
+			pool.counters.completed(res.Duration, res.Err)
+			if pool.concurrency != nil {
+				pool.concurrency.release(generation, res.Duration, res.Err)
+			}
+			if pool.priorityCounters != nil {
+				pool.priorityCounters.completed(t.priority, res.Err)
+			}
+			if pool.tenants != nil {
+				// the tenant may have been waiting for the completion to have another input processed
+				pool.tenants.completed(t.tenant, res.Err)
+				pool.queue.notify()
+			}
+			if pool.queue != nil {
+				pool.queue.completed(t)
+			}
+			if t.future != nil {
+				t.future.complete(res.Output, res.Err)
+				continue
+			}
+			var pe PanicError[I]
+			if pool.repanic && errors.As(res.Err, &pe) {
+				pool.recordPanic(pe)
+			}
+			if res.Err != nil && ctx.Err() != nil {
+				// it the context has signalled a termination signal, exit the worker
+				return
+			}
+			if res.Err != nil && pool.deadLetter != nil {
+				pool.deadLetter.Put(DeadLetterRecord[I]{
+					Input: res.Input, Err: res.Err, Attempts: res.Attempts, StartedAt: res.StartedAt, FailedAt: res.StartedAt.Add(res.Duration),
+				})
+			}
+			if !pool.deliver(ctx, res) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// putBack keeps a task which has been taken from the queue but can not be processed, so that it is returned as unprocessed
+func (pool *Pool[I, O]) putBack(t task[I, O]) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.leftover = append(pool.leftover, t)
+}
+
+// giveBack puts back a task which has been taken by a worker but can not be processed and tells the schedulers, if any,
+// that the task no longer runs
+func (pool *Pool[I, O]) giveBack(t task[I, O]) {
+	pool.putBack(t)
+	if pool.tenants != nil {
+		pool.tenants.givenBack(t.tenant)
+		pool.queue.notify()
+	}
+	if pool.queue != nil {
+		pool.queue.completed(t)
+	}
+}
+
+// invoke calls the do function of the pool passing it a context derived from the context of the pool.
+// The derived context is cancelled as soon as the processing of the input is completed.
+// If the task has been sent with Submit, the derived context is cancelled also when the Future is cancelled or when the context passed
+// to Submit signals.
+// If the pool has a retry policy, the do function is called again while it fails with a retryable error.
+// Returns the output or the error of the processing and the number of times the do function has been called.
+func (pool *Pool[I, O]) invoke(ctx context.Context, t task[I, O]) (O, error, int) {
+	taskCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if t.future != nil {
+		if !t.future.start(cancel) {
+			var zero O
+			return zero, context.Canceled, 0
+		}
+		if t.ctx.Done() != nil {
+			finished := make(chan struct{})
+			defer close(finished)
+			go func() {
+				select {
+				case <-t.ctx.Done():
+					cancel()
+				case <-finished:
+				}
+			}()
+		}
+	}
+	if pool.options.retry == nil {
+		output, err := pool.call(taskCtx, t.input)
+		return output, err, 1
+	}
+	return retry(taskCtx, *pool.options.retry, func() (O, error) {
+		return pool.call(taskCtx, t.input)
+	})
+}
+
+// call calls the do function of the pool once, if the circuit breaker, if any, lets the call through.
+// If the do function panics, the panic is recovered and returned as a PanicError.
+func (pool *Pool[I, O]) call(ctx context.Context, input I) (output O, err error) {
+	if pool.breaker != nil {
+		probe, allowErr := pool.breaker.allow(ctx)
+		if allowErr != nil {
+			return output, allowErr
+		}
+		// this deferred function runs after the one which recovers panics, so that a panic is recorded as a failure
+		defer func() {
+			pool.breaker.record(err, probe)
 		}()
 	}
+	defer func() {
+		if v := recover(); v != nil {
+			err = PanicError[I]{Value: v, Stack: debug.Stack(), Input: input}
+		}
+	}()
+	return pool.do(ctx, input)
 }
 
-// Process sends one value to the pool to be processed by the first available worker.
-func (pool *Pool[I, O]) Process(input I) {
-	pool.inCh <- input
+// recordPanic keeps the first panic recovered, so that Stop can raise it again
+func (pool *Pool[I, O]) recordPanic(pe PanicError[I]) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.panicked == nil {
+		pool.panicked = &pe
+	}
+}
+
+// deliver passes the result of a processing to the sequencer, if the pool is ordered, or emits it directly.
+// Returns false if the context signals before the result could be delivered.
+func (pool *Pool[I, O]) deliver(ctx context.Context, res Result[I, O]) bool {
+	if pool.ordered == nil {
+		return pool.emit(ctx, res)
+	}
+	select {
+	case pool.ordered.in <- res:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// emit sends the result of a processing to the channel it belongs to.
+// Returns false if the context signals before the result could be sent.
+func (pool *Pool[I, O]) emit(ctx context.Context, res Result[I, O]) bool {
+	var pe PanicError[I]
+	if pool.repanic && errors.As(res.Err, &pe) {
+		// the panic is raised again by Stop
+		return true
+	}
+	if pool.ResultCh != nil {
+		select {
+		case pool.ResultCh <- res:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	if res.Err != nil {
+		select {
+		case pool.ErrCh <- res.Err:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	select {
+	case pool.OutCh <- res.Output:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 // Stop stops the pool
-// After the pool is stopped no other input value can be processed
+// After the pool is stopped no other input value can be processed. The values which are being sent to the pool while Stop is called
+// are rejected with ErrPoolStopped. Stop waits for all the values accepted by the pool to be processed.
+// If the pool is created with the WithRepanic option and the processing of an input has panicked, Stop panics with the PanicError
+// after the pool has been stopped.
 func (pool *Pool[I, O]) Stop() {
+	pool.shutdown(context.Background(), false)
+	pool.raisePanic()
+}
+
+// Drain stops the pool like Stop: it stops accepting values and waits for the values in the queue and the ones being processed to complete.
+// If ctx signals before all the values have been processed, the processing of the remaining values is cancelled and Drain returns
+// the error of ctx.
+// If the pool is created with the WithRepanic option and the processing of an input has panicked, Drain panics with the PanicError
+// after the pool has been stopped.
+func (pool *Pool[I, O]) Drain(ctx context.Context) error {
+	_, err := pool.shutdown(ctx, false)
+	pool.raisePanic()
+	return err
+}
+
+// Abort stops the pool immediately: it stops accepting values, cancels the context passed to the values being processed
+// and returns the values which were waiting in the queue and have not been processed.
+// The Futures of the values submitted which have not been processed are completed with ErrPoolStopped.
+func (pool *Pool[I, O]) Abort() []I {
+	unprocessed, _ := pool.shutdown(context.Background(), true)
+	return unprocessed
+}
+
+// shutdown stops the pool, waits for the workers to exit and closes the channels of the pool.
+// If abort is true, or if ctx signals before the workers have exited, the context of the pool is cancelled.
+// Returns the values which have not been processed and, if ctx signalled, its error.
+func (pool *Pool[I, O]) shutdown(ctx context.Context, abort bool) ([]I, error) {
 	pool.mu.Lock()
-	if pool.status == Stopped {
-		return
+	if !pool.status.accepting() {
+		// the pool is already being stopped, wait for it to complete
+		terminated := pool.terminated
+		pool.mu.Unlock()
+		<-terminated
+		return nil, nil
+	}
+	pool.transition(Draining)
+	// a paused pool must process the values in its queue before stopping
+	pool.openGate()
+	started := pool.ctx != nil
+	cancel := pool.cancel
+	if cancel == nil {
+		// the pool has never been started
+		cancel = func() {}
+	}
+	// unblock the goroutines which are sending values and wait for them to give up
+	close(pool.stopping)
+	for pool.senders > 0 {
+		pool.sendersDone.Wait()
 	}
-	pool.status = Stopped
 	pool.mu.Unlock()
-	// close the input channel
-	close(pool.inCh)
+	// close the input channel. The queue, if any, closes it once it has handed all its tasks to the workers.
+	if pool.queue != nil {
+		close(pool.queue.in)
+	}
+	if pool.queue == nil || !started {
+		close(pool.inCh)
+	}
+	if abort {
+		cancel()
+	}
 	// wait for all the values sent to the input channel to go through the processing made by the pool
-	pool.doneWithInput.Wait()
-	// close the output and the error channels
+	workersDone := make(chan struct{})
+	go func() {
+		pool.doneWithInput.Wait()
+		close(workersDone)
+	}()
+	var err error
+	select {
+	case <-workersDone:
+	case <-ctx.Done():
+		err = ctx.Err()
+		cancel()
+		<-workersDone
+	}
+	if pool.queue != nil && started {
+		<-pool.queue.done
+	}
+	// collect the values left in the queue, which is the case if the context of the pool has been cancelled
+	pool.mu.Lock()
+	left := pool.leftover
+	pool.leftover = nil
+	pool.mu.Unlock()
+	for t := range pool.inCh {
+		left = append(left, t)
+	}
+	unprocessed := []I{}
+	for _, t := range left {
+		if t.future != nil {
+			var zero O
+			t.future.complete(zero, ErrPoolStopped)
+		}
+		unprocessed = append(unprocessed, t.input)
+	}
+	// wait for the sequencer, if any, to emit the results it holds
+	if pool.ordered != nil && started {
+		close(pool.ordered.in)
+		<-pool.ordered.done
+	}
+	// close the output, the error and the result channels
 	close(pool.OutCh)
 	close(pool.ErrCh)
+	if pool.ResultCh != nil {
+		close(pool.ResultCh)
+	}
+	// release the resources of the context of the pool
+	cancel()
+	pool.mu.Lock()
+	switch {
+	case abort || err != nil:
+		pool.transition(Aborted)
+	case pool.panicked != nil:
+		pool.transition(Failed)
+	default:
+		pool.transition(Stopped)
+	}
+	pool.mu.Unlock()
+	return unprocessed, err
 }
 
-// GetStatus returns the status of the pool
-func (pool *Pool[I, O]) GetStatus() PoolStatus {
-	var st PoolStatus
+// raisePanic panics with the first panic recovered if the pool is created with the WithRepanic option
+func (pool *Pool[I, O]) raisePanic() {
 	pool.mu.Lock()
-	st = pool.status
+	panicked := pool.panicked
 	pool.mu.Unlock()
-	return st
+	if panicked != nil {
+		panic(*panicked)
+	}
+}
+
+// QueueLen returns the number of values which have been sent to the pool and are waiting for a worker to be available
+func (pool *Pool[I, O]) QueueLen() int {
+	// the channel and the queue are replaced when the pool is reset, so they are read holding the lock
+	pool.mu.Lock()
+	inCh, queue := pool.inCh, pool.queue
+	pool.mu.Unlock()
+	if queue != nil {
+		return len(inCh) + queue.Len()
+	}
+	return len(inCh)
 }
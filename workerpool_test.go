@@ -186,3 +186,39 @@ func TestPoolOneWorker(t *testing.T) {
 		t.Errorf("Expected sum of the numbers received %v - got %v", expectedSum, gotSum)
 	}
 }
+
+// TestPoolWithContext checks that the context passed to the function of a pool created with NewWithContext
+// is cancelled when the context passed to Start is cancelled.
+// The function of the pool blocks until its context is cancelled and then returns the context error.
+func TestPoolWithContext(t *testing.T) {
+	started := make(chan struct{})
+	do := func(ctx context.Context, in int) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	pool := workerpool.NewWithContext(1, do)
+	ctx, cancel := context.WithCancel(context.Background())
+	pool.Start(ctx)
+
+	go pool.Process(1)
+	// wait for the function to start and then cancel the context
+	<-started
+	cancel()
+
+	// since the context has been cancelled, the pool can be stopped even if the function has never returned a result
+	pool.Stop()
+
+	gotNumOfResults := 0
+	for range pool.OutCh {
+		gotNumOfResults++
+	}
+	if gotNumOfResults != 0 {
+		t.Errorf("Expected number of results %v - got %v", 0, gotNumOfResults)
+	}
+	expectedPoolStatus := workerpool.Stopped
+	gotStatus := pool.GetStatus()
+	if expectedPoolStatus != gotStatus {
+		t.Errorf("Expected pool status %v - got %v", expectedPoolStatus, gotStatus)
+	}
+}